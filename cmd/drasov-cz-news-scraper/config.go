@@ -0,0 +1,103 @@
+/*
+ * www.drasov.cz/uredni-deska news scraper
+ *
+ * Copyright (C) 2023  Tomáš Hozza
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// applyConfigFile sets the flags of the flag set from the JSON configuration file.
+//
+// The configuration file is a JSON object whose keys are the flag names, e.g.:
+//
+//	{"days": 7, "format": "csv", "exclude-title": ["Pozvánka"]}
+//
+// Repeatable flags take an array of values. Flags which were set explicitly on the command line
+// take precedence over the values from the configuration file. Unknown keys are reported as an error.
+func applyConfigFile(fs *flag.FlagSet, filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var values map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return fmt.Errorf("error while parsing configuration file %s: %w", filename, err)
+	}
+
+	explicitlySet := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		explicitlySet[f.Name] = true
+	})
+
+	names := maps.Keys(values)
+	sort.Strings(names)
+
+	var unknown []string
+	for _, name := range names {
+		if fs.Lookup(name) == nil || name == "config" {
+			unknown = append(unknown, name)
+			continue
+		}
+		if explicitlySet[name] {
+			continue
+		}
+
+		flagValues, err := configValueToFlagValues(values[name])
+		if err != nil {
+			return fmt.Errorf("invalid value of %q in configuration file %s: %w", name, filename, err)
+		}
+		for _, flagValue := range flagValues {
+			if err := fs.Set(name, flagValue); err != nil {
+				return fmt.Errorf("invalid value of %q in configuration file %s: %w", name, filename, err)
+			}
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown keys in configuration file %s: %s", filename, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// configValueToFlagValues converts a value from the configuration file to the flag values.
+// An array is converted to multiple values of a repeatable flag.
+func configValueToFlagValues(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case json.Number:
+		return []string{v.String()}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case []any:
+		var flagValues []string
+		for _, item := range v {
+			if _, ok := item.([]any); ok {
+				return nil, fmt.Errorf("nested arrays are not supported")
+			}
+			itemValues, err := configValueToFlagValues(item)
+			if err != nil {
+				return nil, err
+			}
+			flagValues = append(flagValues, itemValues...)
+		}
+		return flagValues, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", value)
+}
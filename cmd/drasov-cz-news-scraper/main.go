@@ -7,15 +7,34 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
+	"github.com/gocolly/colly/v2/debug"
+	"github.com/gocolly/colly/v2/queue"
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
 )
 
 const dateFormat = "Mon 02.01.2006"
@@ -30,190 +49,2370 @@ func (n NewsEntryAttachment) String() string {
 }
 
 type NewsEntry struct {
-	PublishedOn    *time.Time
+	Source string
+	// PublishedOn is the date since which the document is posted on the board ("Vyvěšeno"), which
+	// is not necessarily the date when the document was issued.
+	PublishedOn *time.Time
+	// PublishedUntil is the date until which the document is posted on the board ("Sejmuto").
 	PublishedUntil *time.Time
 	Title          string
+	Category       string
 	EntryURL       string
 	Attachments    []NewsEntryAttachment
+	// PublishedUntilInferred is set, if PublishedUntil is missing on the board and was inferred
+	// from the text of the details page instead.
+	PublishedUntilInferred bool
+	// ElectronicOnly is set, if the document is published only electronically and not physically
+	// posted on the notice board.
+	ElectronicOnly bool
+	// Index is the position of the news entry on the board of its source, starting at 0.
+	Index int
+	// ReferenceNumber is the reference number ("číslo jednací") of the document, as stated on the details
+	// page. It is empty, if the details page doesn't state it.
+	ReferenceNumber string
+	// Authority is the office or person, which issued the document, e.g. "Stavební úřad", as stated
+	// on the details page. It is empty, if the details page doesn't state it.
+	Authority string
+	// RawHTML is the HTML of the news entry on the board listing, kept only with ScraperConfig.KeepRaw
+	// for debugging of the extraction, see DebugRenderer.
+	RawHTML string
 }
 
 func (n NewsEntry) String() string {
+	return n.format(false)
+}
+
+// format returns a string representation of the news entry. If groupAttachments is set, the attachments
+// with the same filename are written on a single line, see GroupAttachmentsByName.
+func (n NewsEntry) format(groupAttachments bool) string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Title: %s\n", n.Title))
-	sb.WriteString(fmt.Sprintf("Published on: %s\n", n.PublishedOn.Format(dateFormat)))
-	sb.WriteString(fmt.Sprintf("Published until: %s\n", n.PublishedUntil.Format(dateFormat)))
-	sb.WriteString(fmt.Sprintf("URL: %s\n", n.EntryURL))
-	if len(n.Attachments) > 0 {
-		sb.WriteString("Attachments:\n")
+	n.writeTo(&sb, groupAttachments)
+	return sb.String()
+}
+
+// writeTo writes the string representation of the news entry to the builder, see format. It avoids
+// fmt, which is considerably slower and allocates for each line, when formatting large boards.
+func (n NewsEntry) writeTo(sb *strings.Builder, groupAttachments bool) {
+	writeLine := func(name, value string) {
+		sb.WriteString(name)
+		sb.WriteString(": ")
+		sb.WriteString(value)
+		sb.WriteByte('\n')
+	}
+
+	writeLine("Title", n.Title)
+	writeLine("Source", n.Source)
+	if n.Category != "" {
+		writeLine("Category", n.Category)
+	}
+	writeLine("Published on", formatDate(n.PublishedOn))
+	if n.PublishedUntilInferred {
+		writeLine("Published until", formatDate(n.PublishedUntil)+" (inferred)")
+	} else {
+		writeLine("Published until", formatDate(n.PublishedUntil))
+	}
+	if n.ElectronicOnly {
+		writeLine("Electronic only", "yes")
+	}
+	if n.ReferenceNumber != "" {
+		writeLine("Reference number", n.ReferenceNumber)
+	}
+	if n.Authority != "" {
+		writeLine("Authority", n.Authority)
+	}
+	writeLine("URL", n.EntryURL)
+	if len(n.Attachments) == 0 {
+		return
+	}
+
+	sb.WriteString("Attachments:\n")
+	if !groupAttachments {
 		for _, attachment := range n.Attachments {
-			sb.WriteString(fmt.Sprintf("  %s\n", attachment.String()))
+			writeLine("  "+attachment.Filename, attachment.URL)
+		}
+		return
+	}
+	groups := n.GroupAttachmentsByName()
+	for _, attachment := range n.Attachments {
+		group, ok := groups[attachment.Filename]
+		if !ok {
+			// already written with the first attachment of the group
+			continue
+		}
+		delete(groups, attachment.Filename)
+		sb.WriteString("  ")
+		sb.WriteString(attachment.Filename)
+		sb.WriteString(": ")
+		for idx, variant := range group {
+			if idx > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(variant.URL)
 		}
+		sb.WriteByte('\n')
 	}
-	return sb.String()
+}
+
+// GroupAttachmentsByName returns the attachments grouped by their filename, e.g. the variants of the same
+// document in different formats. The attachments in a group are in the order of the Attachments field.
+func (n *NewsEntry) GroupAttachmentsByName() map[string][]NewsEntryAttachment {
+	groups := map[string][]NewsEntryAttachment{}
+	for _, attachment := range n.Attachments {
+		groups[attachment.Filename] = append(groups[attachment.Filename], attachment)
+	}
+	return groups
+}
+
+// URL returns the parsed EntryURL. It returns an error if the EntryURL is not a valid absolute URL.
+func (n *NewsEntry) URL() (*url.URL, error) {
+	u, err := url.Parse(n.EntryURL)
+	if err != nil {
+		return nil, err
+	}
+	if !u.IsAbs() {
+		return nil, fmt.Errorf("news entry URL is not absolute: %q", n.EntryURL)
+	}
+	return u, nil
+}
+
+// IsExpired reports whether the news entry was no longer supposed to be published at the given time,
+// i.e. its PublishedUntil date is strictly before the given time. An entry with unknown PublishedUntil
+// date never expires.
+func (n *NewsEntry) IsExpired(at time.Time) bool {
+	return n.PublishedUntil != nil && n.PublishedUntil.Before(at)
 }
 
 type News []*NewsEntry
 
 // Since returns all news entries that were published since the given time, including the given time.
+// Entries with unknown publication date are always included, because they can't be ruled out.
 func (n News) SinceIncluding(t time.Time) News {
 	var news News
 	for _, newsEntry := range n {
-		if newsEntry.PublishedOn.After(t) || newsEntry.PublishedOn.Equal(t) {
+		if newsEntry.PublishedOn == nil || newsEntry.PublishedOn.After(t) || newsEntry.PublishedOn.Equal(t) {
 			news = append(news, newsEntry)
 		}
 	}
 	return news
 }
 
-// String returns a string representation of the news entries.
-func (n News) String() string {
-	var sb strings.Builder
-	for idx, newsEntry := range n {
-		sb.WriteString(newsEntry.String())
-		if idx < len(n)-1 {
-			sb.WriteString("\n")
+// ExcludeExpired returns all news entries that are not expired at the given time.
+// See NewsEntry.IsExpired for details.
+func (n News) ExcludeExpired(at time.Time) News {
+	var news News
+	for _, newsEntry := range n {
+		if !newsEntry.IsExpired(at) {
+			news = append(news, newsEntry)
 		}
 	}
-	return sb.String()
+	return news
 }
 
-// NowDate returns the current date without the clock time, ignoring the timezone.
-func NowDate() time.Time {
-	now := time.Now()
-	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+// Partition splits the news entries by their publication state at the given time. Upcoming entries
+// are published after the given time, expired entries are expired at the given time, see
+// NewsEntry.IsExpired, and all other entries are active. Entries with unknown PublishedOn date
+// are never upcoming and entries with unknown PublishedUntil date are never expired.
+func (n News) Partition(at time.Time) (upcoming, active, expired News) {
+	for _, newsEntry := range n {
+		switch {
+		case newsEntry.IsExpired(at):
+			expired = append(expired, newsEntry)
+		case newsEntry.PublishedOn != nil && newsEntry.PublishedOn.After(at):
+			upcoming = append(upcoming, newsEntry)
+		default:
+			active = append(active, newsEntry)
+		}
+	}
+	return upcoming, active, expired
 }
 
-// StringDateToTime converts a string date in the format "DD. MM. YYYY" to a time.Time object.
-func StringDateToTime(date string) (*time.Time, error) {
-	// expected format: "1. 12. 2021"
-	parts := strings.Split(date, ".")
+// OverlappingWindow returns all news entries, which are published at any time within the window from start
+// to end, inclusive, i.e. whose [PublishedOn, PublishedUntil] interval intersects the window. Entries with
+// unknown PublishedOn date are considered to be published since ever and entries with unknown PublishedUntil
+// date are considered to be published indefinitely.
+func (n News) OverlappingWindow(start, end time.Time) News {
+	var news News
+	for _, newsEntry := range n {
+		if (newsEntry.PublishedOn == nil || !newsEntry.PublishedOn.After(end)) &&
+			(newsEntry.PublishedUntil == nil || !newsEntry.PublishedUntil.Before(start)) {
+			news = append(news, newsEntry)
+		}
+	}
+	return news
+}
 
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("unexpected date format: %s", date)
+// ValidUntil returns all news entries that are supposed to be published at least until the given time,
+// i.e. their PublishedUntil date is not before the given time. Entries with unknown PublishedUntil date
+// are considered to be published indefinitely.
+func (n News) ValidUntil(t time.Time) News {
+	var news News
+	for _, newsEntry := range n {
+		if newsEntry.PublishedUntil == nil || !newsEntry.PublishedUntil.Before(t) {
+			news = append(news, newsEntry)
+		}
 	}
+	return news
+}
 
-	day, err := strconv.Atoi(strings.TrimSpace(parts[0]))
-	if err != nil {
-		return nil, err
+// FilterByDisplayDuration returns all news entries, which are published for at least the given duration,
+// i.e. from their PublishedOn date to their PublishedUntil date. Entries with unknown PublishedOn or
+// PublishedUntil date are excluded, as their duration can't be computed.
+func (n News) FilterByDisplayDuration(min time.Duration) News {
+	var news News
+	for _, newsEntry := range n {
+		if newsEntry.PublishedOn == nil || newsEntry.PublishedUntil == nil {
+			continue
+		}
+		if newsEntry.PublishedUntil.Sub(*newsEntry.PublishedOn) >= min {
+			news = append(news, newsEntry)
+		}
 	}
+	return news
+}
 
-	month, err := strconv.Atoi(strings.TrimSpace(parts[1]))
-	if err != nil {
-		return nil, err
+// MissingPublishedUntil returns all news entries with unknown PublishedUntil date.
+func (n News) MissingPublishedUntil() News {
+	var news News
+	for _, newsEntry := range n {
+		if newsEntry.PublishedUntil == nil {
+			news = append(news, newsEntry)
+		}
 	}
+	return news
+}
 
-	year, err := strconv.Atoi(strings.TrimSpace(parts[2]))
-	if err != nil {
-		return nil, err
+// WithoutAttachments returns all news entries that have no attachments.
+func (n News) WithoutAttachments() News {
+	var news News
+	for _, newsEntry := range n {
+		if len(newsEntry.Attachments) == 0 {
+			news = append(news, newsEntry)
+		}
 	}
+	return news
+}
 
-	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
-	return &t, nil
+// FilterByCategory returns all news entries of the given category, compared case-insensitively.
+func (n News) FilterByCategory(category string) News {
+	var news News
+	for _, newsEntry := range n {
+		if strings.EqualFold(newsEntry.Category, category) {
+			news = append(news, newsEntry)
+		}
+	}
+	return news
+}
+
+// FilterByCategories returns all news entries of any of the given categories, compared case-insensitively.
+// If no categories are given, all news entries are returned.
+func (n News) FilterByCategories(categories ...string) News {
+	if len(categories) == 0 {
+		return n
+	}
+	var news News
+	for _, newsEntry := range n {
+		for _, category := range categories {
+			if strings.EqualFold(newsEntry.Category, category) {
+				news = append(news, newsEntry)
+				break
+			}
+		}
+	}
+	return news
 }
 
-// ScrapeNewsEntries scrapes all news entries from the www.drasov.cz/uredni-deska website.
-func ScrapeNewsEntries(debug bool) (News, error) {
-	// map of news entries by their URL
-	news := map[string]*NewsEntry{}
+// FilterByWeekday returns all news entries published on any of the given days of the week.
+// Entries with unknown publication date are excluded.
+func (n News) FilterByWeekday(days ...time.Weekday) News {
+	var news News
+	for _, newsEntry := range n {
+		if newsEntry.PublishedOn != nil && slices.Contains(days, newsEntry.PublishedOn.Weekday()) {
+			news = append(news, newsEntry)
+		}
+	}
+	return news
+}
 
-	allowedDomains := colly.AllowedDomains("drasov.cz", "www.drasov.cz")
+// ParseWeekday parses the English name of a day of the week, either full or abbreviated to its first
+// three letters, e.g. "Monday" or "Mon", case-insensitive.
+func ParseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(name, day.String()) || strings.EqualFold(name, day.String()[:3]) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day of the week: %q", name)
+}
 
-	detailsCollector := colly.NewCollector(allowedDomains)
+// FilterByAttachmentName returns all news entries with at least one attachment whose filename matches
+// the given shell pattern, e.g. "*rozpocet*.pdf". See filepath.Match for the pattern syntax. The matching
+// is case-insensitive. If the pattern is malformed, no entries are returned.
+func (n News) FilterByAttachmentName(pattern string) News {
+	pattern = strings.ToLower(pattern)
 
-	detailsCollector.OnRequest(func(r *colly.Request) {
-		if debug {
-			fmt.Fprintf(os.Stderr, "Visiting %s\n", r.URL)
+	var news News
+	for _, newsEntry := range n {
+		for _, attachment := range newsEntry.Attachments {
+			if matched, _ := filepath.Match(pattern, strings.ToLower(attachment.Filename)); matched {
+				news = append(news, newsEntry)
+				break
+			}
 		}
-	})
+	}
+	return news
+}
 
-	detailsCollector.OnHTML(".c-card", func(e *colly.HTMLElement) {
-		newsEntry, ok := news[e.Request.URL.String()]
-		if !ok {
-			panic(fmt.Sprintf("news entry not found for URL %s", e.Request.URL))
+// ExcludeTitle returns all news entries whose title does not contain the given substring.
+// If caseInsensitive is true, the title and the substring are compared case-insensitively.
+func (n News) ExcludeTitle(substr string, caseInsensitive bool) News {
+	var news News
+	for _, newsEntry := range n {
+		if !titleContains(newsEntry.Title, substr, caseInsensitive) {
+			news = append(news, newsEntry)
 		}
+	}
+	return news
+}
 
-		// extract attachments
-		e.ForEach(".c-files-wrapper", func(_ int, e *colly.HTMLElement) {
-			newsEntry.Attachments = append(newsEntry.Attachments, NewsEntryAttachment{
-				Filename: e.ChildText("h3"),
-				URL:      e.ChildAttr("a", "href"),
-			})
-		})
-	})
+// FilterByTitleRegex returns all news entries whose title matches the given regular expression.
+// The matching is case-sensitive, unless the pattern uses the "(?i)" flag.
+func (n News) FilterByTitleRegex(re *regexp.Regexp) News {
+	var news News
+	for _, newsEntry := range n {
+		if re.MatchString(newsEntry.Title) {
+			news = append(news, newsEntry)
+		}
+	}
+	return news
+}
 
-	allEntriesCollector := colly.NewCollector(allowedDomains)
+// PhysicallyPosted returns all news entries, which are physically posted on the notice board,
+// i.e. which are not ElectronicOnly.
+func (n News) PhysicallyPosted() News {
+	var news News
+	for _, newsEntry := range n {
+		if !newsEntry.ElectronicOnly {
+			news = append(news, newsEntry)
+		}
+	}
+	return news
+}
 
-	allEntriesCollector.OnRequest(func(r *colly.Request) {
-		if debug {
-			fmt.Fprintf(os.Stderr, "Visiting %s\n", r.URL)
+// FilterByAuthority returns all news entries whose authority contains the given text, case-insensitive.
+func (n News) FilterByAuthority(substr string) News {
+	var news News
+	for _, newsEntry := range n {
+		if strings.Contains(strings.ToLower(newsEntry.Authority), strings.ToLower(substr)) {
+			news = append(news, newsEntry)
 		}
-	})
+	}
+	return news
+}
 
-	allEntriesCollector.OnHTML(".c-office-board", func(e *colly.HTMLElement) {
-		// iterate over all news entries
-		e.ForEach(".c-office-board__content-item", func(_ int, e *colly.HTMLElement) {
-			newsEntry := NewsEntry{}
+// FilterByReferenceNumber returns all news entries whose reference number contains the given text,
+// case-insensitive.
+func (n News) FilterByReferenceNumber(substr string) News {
+	var news News
+	for _, newsEntry := range n {
+		if strings.Contains(strings.ToLower(newsEntry.ReferenceNumber), strings.ToLower(substr)) {
+			news = append(news, newsEntry)
+		}
+	}
+	return news
+}
 
-			// extract PublishedOn and PublishedUntil dates
-			e.ForEach(".c-office-board__col-date", func(idx int, e *colly.HTMLElement) {
-				date, err := StringDateToTime(e.ChildTexts("span")[1])
-				if err != nil {
-					panic(fmt.Sprintf("error while parsing date: %s", err))
-				}
+// FilterByURLPattern returns all news entries whose URL matches the given regular expression.
+// This allows selecting a section of the board, e.g. with the pattern "/uredni-deska/vyhlasky/".
+func (n News) FilterByURLPattern(re *regexp.Regexp) News {
+	var news News
+	for _, newsEntry := range n {
+		if re.MatchString(newsEntry.EntryURL) {
+			news = append(news, newsEntry)
+		}
+	}
+	return news
+}
 
-				if idx == 0 {
-					newsEntry.PublishedOn = date
-				} else if idx == 1 {
-					newsEntry.PublishedUntil = date
-				} else {
-					panic("unexpected index while iterating over .c-office-board__col-date")
-				}
-			})
+// titleContains reports whether the title contains the given substring.
+// Case folding uses Unicode rules, so it works for Czech diacritics as well (e.g. "Č" and "č").
+func titleContains(title, substr string, caseInsensitive bool) bool {
+	if caseInsensitive {
+		return strings.Contains(strings.ToLower(title), strings.ToLower(substr))
+	}
+	return strings.Contains(title, substr)
+}
 
-			// extract Title and EntryURL
-			e.ForEachWithBreak(".c-office-board__col-name-content", func(_ int, e *colly.HTMLElement) bool {
-				newsEntry.Title = e.ChildText("a")
-				newsEntry.EntryURL = fmt.Sprintf("https://www.drasov.cz%s", e.ChildAttr("a", "href"))
-				return false
-			})
+// Merge returns the union of the news entries and the other news entries, identified by their URL.
+// If both contain an entry with the same URL, the entry from the receiver takes precedence.
+// The result is sorted.
+func (n News) Merge(other News) News {
+	seen := make(map[string]bool, len(n))
+	news := make(News, 0, len(n)+len(other))
+	for _, newsEntry := range n {
+		seen[newsEntry.EntryURL] = true
+		news = append(news, newsEntry)
+	}
+	for _, newsEntry := range other {
+		if !seen[newsEntry.EntryURL] {
+			seen[newsEntry.EntryURL] = true
+			news = append(news, newsEntry)
+		}
+	}
+	return news.Sorted()
+}
 
-			news[newsEntry.EntryURL] = &newsEntry
-			err := detailsCollector.Visit(newsEntry.EntryURL)
-			if err != nil {
-				panic(fmt.Sprintf("error while collecting details from %s: %s", newsEntry.EntryURL, err))
-			}
-		})
+// Sorted returns a copy of the news entries sorted from the most recently published one.
+// Entries with unknown publication date are sorted last. Entries published on the same date are
+// sorted by their title and URL, so that the order is stable.
+func (n News) Sorted() News {
+	news := make(News, len(n))
+	copy(news, n)
+	sort.Slice(news, func(i, j int) bool {
+		a, b := news[i], news[j]
+		switch {
+		case a.PublishedOn == nil && b.PublishedOn != nil:
+			return false
+		case a.PublishedOn != nil && b.PublishedOn == nil:
+			return true
+		case a.PublishedOn != nil && !a.PublishedOn.Equal(*b.PublishedOn):
+			return a.PublishedOn.After(*b.PublishedOn)
+		case a.Title != b.Title:
+			return a.Title < b.Title
+		}
+		return a.EntryURL < b.EntryURL
 	})
+	return news
+}
 
-	err := allEntriesCollector.Visit("https://www.drasov.cz/uredni-deska")
-	if err != nil {
-		return nil, err
+// InDiscoveryOrder returns a copy of the news entries in the order in which they are listed on the boards,
+// see NewsEntry.Index. The entries of each source are kept together, in the order of the first entry of
+// each source.
+func (n News) InDiscoveryOrder() News {
+	sourceRanks := map[string]int{}
+	for _, newsEntry := range n {
+		if _, ok := sourceRanks[newsEntry.Source]; !ok {
+			sourceRanks[newsEntry.Source] = len(sourceRanks)
+		}
 	}
 
-	allEntriesCollector.Wait()
-	detailsCollector.Wait()
+	news := make(News, len(n))
+	copy(news, n)
+	sort.SliceStable(news, func(i, j int) bool {
+		a, b := news[i], news[j]
+		if a.Source != b.Source {
+			return sourceRanks[a.Source] < sourceRanks[b.Source]
+		}
+		return a.Index < b.Index
+	})
+	return news
+}
 
-	return maps.Values(news), nil
+// Limit returns at most the first count news entries.
+func (n News) Limit(count int) News {
+	if len(n) <= count {
+		return n
+	}
+	return n[:count]
 }
 
-func main() {
-	minusDays := flag.Int("days", 30, "filter news entries published in the last N days")
-	debug := flag.Bool("debug", false, "enable debug mode")
-	flag.Parse()
+// EntryCount returns the number of news entries.
+func (n News) EntryCount() int {
+	return len(n)
+}
 
-	sinceDate := NowDate().AddDate(0, 0, -*minusDays)
+// TotalAttachments returns the total number of attachments of all news entries.
+func (n News) TotalAttachments() int {
+	total := 0
+	for _, newsEntry := range n {
+		total += len(newsEntry.Attachments)
+	}
+	return total
+}
 
-	news, err := ScrapeNewsEntries(*debug)
-	if err != nil {
-		panic(err)
+// PostingFrequency returns the number of news entries posted in each month, keyed by the month in the format
+// "2006-01". Entries with unknown PublishedOn date are skipped.
+func (n News) PostingFrequency() map[string]int {
+	frequency := map[string]int{}
+	for _, newsEntry := range n {
+		if newsEntry.PublishedOn != nil {
+			frequency[newsEntry.PublishedOn.Format("2006-01")]++
+		}
 	}
+	return frequency
+}
 
-	filteredNews := news.SinceIncluding(sinceDate)
-	if len(filteredNews) == 0 {
-		fmt.Printf("Found no news entries published since %s\n", sinceDate.Format(dateFormat))
-		return
+// DuplicateTitles groups the news entries by their normalized, case-insensitive title and returns the groups
+// of more than one entry, e.g. an announcement accidentally posted twice under different URLs.
+func (n News) DuplicateTitles() map[string]News {
+	byTitle := map[string]News{}
+	for _, newsEntry := range n {
+		title := strings.ToLower(normalizeTitle(newsEntry.Title))
+		byTitle[title] = append(byTitle[title], newsEntry)
+	}
+	maps.DeleteFunc(byTitle, func(_ string, entries News) bool {
+		return len(entries) < 2
+	})
+	return byTitle
+}
+
+// SummaryStats holds the aggregate statistics of news entries.
+type SummaryStats struct {
+	// Entries is the number of news entries.
+	Entries int
+	// WithAttachments is the number of news entries with at least one attachment.
+	WithAttachments int
+	// Attachments is the total number of attachments of all news entries.
+	Attachments int
+	// Oldest and Newest are the earliest and latest PublishedOn dates, nil if no entry has the date.
+	Oldest, Newest *time.Time
+	// ExpiringThisWeek is the number of news entries, which are published until a date within 7 days
+	// from the summary time.
+	ExpiringThisWeek int
+}
+
+// Summary computes the aggregate statistics of the news entries at the given time.
+func (n News) Summary(at time.Time) SummaryStats {
+	stats := SummaryStats{
+		Entries:     n.EntryCount(),
+		Attachments: n.TotalAttachments(),
+	}
+	weekLater := at.AddDate(0, 0, 7)
+	for _, newsEntry := range n {
+		if len(newsEntry.Attachments) > 0 {
+			stats.WithAttachments++
+		}
+		if on := newsEntry.PublishedOn; on != nil {
+			if stats.Oldest == nil || on.Before(*stats.Oldest) {
+				stats.Oldest = on
+			}
+			if stats.Newest == nil || on.After(*stats.Newest) {
+				stats.Newest = on
+			}
+		}
+		if !newsEntry.IsExpired(at) && newsEntry.PublishedUntil != nil && newsEntry.PublishedUntil.Before(weekLater) {
+			stats.ExpiringThisWeek++
+		}
+	}
+	return stats
+}
+
+// String returns a string representation of the news entries.
+func (n News) String() string {
+	return n.format(false)
+}
+
+// format returns a string representation of the news entries, see NewsEntry.format.
+func (n News) format(groupAttachments bool) string {
+	var sb strings.Builder
+	// a rough estimate of the size of an entry, to avoid growing the builder repeatedly
+	sb.Grow(len(n) * 256)
+	for idx, newsEntry := range n {
+		newsEntry.writeTo(&sb, groupAttachments)
+		if idx < len(n)-1 {
+			sb.WriteString("\n")
+		}
 	}
+	return sb.String()
+}
 
-	fmt.Printf("Found %d news entries published since %s:\n", len(filteredNews), sinceDate.Format(dateFormat))
-	fmt.Println(news.SinceIncluding(sinceDate))
+// formatDate formats the given date using dateFormat, or returns "unknown" if the date is not set.
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return "unknown"
+	}
+	return t.Format(dateFormat)
+}
+
+// NowDate returns the current date without the clock time, ignoring the timezone.
+func NowDate() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DateParseError is returned when a date string can't be parsed.
+type DateParseError struct {
+	// Date is the raw date string.
+	Date string
+	// Field is the part of the date that failed to parse, i.e. "day", "month", "year" or "time".
+	// It is empty, if the date doesn't have the expected format at all.
+	Field string
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *DateParseError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("unexpected date format: %q", e.Date)
+	}
+	return fmt.Sprintf("invalid %s in date %q: %s", e.Field, e.Date, e.Err)
+}
+
+func (e *DateParseError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	// minYear is the earliest year accepted in the dates on the board.
+	minYear = 2000
+	// maxYearsAhead is the number of years after the current year accepted in the dates on the board.
+	maxYearsAhead = 5
+)
+
+// pragueLocation is the time zone of the times on the board. It falls back to UTC, if the time zone
+// database is not available.
+var pragueLocation = func() *time.Location {
+	loc, err := time.LoadLocation("Europe/Prague")
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}()
+
+// StringDateToTime converts a string date in the format "DD. MM. YYYY", optionally followed by the time
+// in the format "HH:MM", to a time.Time object. The date without the time is midnight in UTC, while the date
+// with the time is in the Europe/Prague time zone. It returns a *DateParseError, if the date can't be parsed,
+// or if it doesn't exist, e.g. "31. 2. 2021".
+func StringDateToTime(date string) (*time.Time, error) {
+	// expected format: "1. 12. 2021" or "1. 12. 2021 14:30"
+	parts := strings.Split(date, ".")
+
+	if len(parts) != 3 {
+		return nil, &DateParseError{Date: date}
+	}
+
+	day, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return nil, &DateParseError{Date: date, Field: "day", Err: err}
+	}
+
+	month, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, &DateParseError{Date: date, Field: "month", Err: err}
+	}
+
+	yearAndTime := strings.Fields(parts[2])
+	if len(yearAndTime) == 0 || len(yearAndTime) > 2 {
+		return nil, &DateParseError{Date: date}
+	}
+
+	year, err := strconv.Atoi(yearAndTime[0])
+	if err != nil {
+		return nil, &DateParseError{Date: date, Field: "year", Err: err}
+	}
+	// a glitch in the markup may produce an absurd year, e.g. 202 or 20210
+	if maxYear := time.Now().Year() + maxYearsAhead; year < minYear || year > maxYear {
+		return nil, &DateParseError{Date: date, Field: "year", Err: fmt.Errorf("%d is not between %d and %d", year, minYear, maxYear)}
+	}
+	if month < 1 || month > 12 {
+		return nil, &DateParseError{Date: date, Field: "month", Err: fmt.Errorf("%d is not between 1 and 12", month)}
+	}
+	// time.Date would normalize an overflowing day, e.g. 31. 2. to 3. 3.
+	if days := time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day(); day < 1 || day > days {
+		return nil, &DateParseError{Date: date, Field: "day", Err: fmt.Errorf("%d is not between 1 and %d", day, days)}
+	}
+
+	if len(yearAndTime) == 1 {
+		t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		return &t, nil
+	}
+
+	clock, err := time.Parse("15:04", yearAndTime[1])
+	if err != nil {
+		return nil, &DateParseError{Date: date, Field: "time", Err: err}
+	}
+	t := time.Date(year, time.Month(month), day, clock.Hour(), clock.Minute(), 0, 0, pragueLocation)
+	return &t, nil
+}
+
+// publishedUntilRe matches the date until which an announcement is published, as stated in its text,
+// e.g. "vyvěšeno do 15. 12. 2023".
+var publishedUntilRe = regexp.MustCompile(`(?i)(?:^|\s)do\s+(\d{1,2}\.\s*\d{1,2}\.\s*\d{4})`)
+
+// referenceNumberRe matches the reference number of a document, e.g. "č. j.: MUDR 1234/2023".
+// The number may be prefixed by a single word, but must contain a digit.
+var referenceNumberRe = regexp.MustCompile(`(?i)č\.\s*j\.\s*:?\s*((?:\p{L}+\s+)?[\p{L}\p{N}/.\-]*\d[\p{L}\p{N}/.\-]*)`)
+
+// FindReferenceNumber returns the first reference number in the text, or an empty string
+// if the text contains none.
+func FindReferenceNumber(text string) string {
+	match := referenceNumberRe.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimRight(match[1], ".")
+}
+
+// authorityRe matches the issuing office or person of a document, following one of the labels used
+// on the details pages, e.g. "Původce: Stavební úřad", up to the end of the line, which is matched
+// within a single text element, see findInTextElements.
+var authorityRe = regexp.MustCompile(`(?i)(?:původce|vydal|zveřejnil|vyvěsil|odpovědný útvar|odbor)\s*:\s*([^\n]+)`)
+
+// FindAuthority returns the first issuing authority in the text, or an empty string if the text
+// contains none.
+func FindAuthority(text string) string {
+	match := authorityRe.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+	return normalizeTitle(match[1])
+}
+
+// InferPublishedUntil returns the first date in the text, which follows the Czech "do" (until),
+// or nil if the text contains no such date.
+func InferPublishedUntil(text string) *time.Time {
+	match := publishedUntilRe.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+	date, err := StringDateToTime(match[1])
+	if err != nil {
+		return nil
+	}
+	return date
+}
+
+type dateColumnKind int
+
+const (
+	dateColumnUnknown dateColumnKind = iota
+	dateColumnPublishedOn
+	dateColumnPublishedUntil
+)
+
+// dateRe matches a date in the format "DD. MM. YYYY", optionally followed by the time in the format "HH:MM".
+// It matches a year of any length, so that StringDateToTime rejects invalid years, instead of matching only
+// a part of them.
+var dateRe = regexp.MustCompile(`\d{1,2}\.\s*\d{1,2}\.\s*\d+(?:\s+\d{1,2}:\d{2})?`)
+
+// parseDateColumn finds the date among the texts of the spans of a date column, regardless of their order,
+// and returns it together with the label of the column, e.g. "Vyvěšeno". The label is either the text of
+// another span, or the rest of the text of the span with the date. It is empty, if the column has no label.
+func parseDateColumn(spans []string) (string, *time.Time, error) {
+	var label string
+	var date *time.Time
+	for _, span := range spans {
+		loc := dateRe.FindStringIndex(span)
+		if loc == nil || date != nil {
+			if label == "" {
+				label = strings.TrimSpace(span)
+			}
+			continue
+		}
+
+		var err error
+		date, err = StringDateToTime(span[loc[0]:loc[1]])
+		if err != nil {
+			return "", nil, err
+		}
+		if rest := strings.TrimSpace(span[:loc[0]] + span[loc[1]:]); label == "" && rest != "" {
+			label = rest
+		}
+	}
+	if date == nil {
+		return "", nil, &DateParseError{Date: strings.Join(spans, " ")}
+	}
+	return label, date, nil
+}
+
+// dateColumnKindFromLabel determines which date a date column holds based on its label,
+// e.g. "Vyvěšeno" or "Sejmuto". If the label is not recognized, it falls back to the position
+// of the column in the entry.
+func dateColumnKindFromLabel(label string, idx int) dateColumnKind {
+	label = strings.ToLower(strings.TrimSpace(label))
+	switch {
+	case strings.HasPrefix(label, "sejm"), strings.Contains(label, " do"):
+		return dateColumnPublishedUntil
+	case strings.HasPrefix(label, "vyvěš"), strings.HasPrefix(label, "zveřejn"):
+		return dateColumnPublishedOn
+	}
+
+	switch idx {
+	case 0:
+		return dateColumnPublishedOn
+	case 1:
+		return dateColumnPublishedUntil
+	}
+	return dateColumnUnknown
+}
+
+// Source is a news board to scrape. All sources are expected to share the same layout
+// as the www.drasov.cz/uredni-deska board.
+type Source struct {
+	// Name identifies the source in the scraped news entries.
+	Name string
+	// URL is the URL of the news board.
+	URL string
+}
+
+// DefaultSource is the www.drasov.cz/uredni-deska news board.
+var DefaultSource = Source{
+	Name: "drasov.cz",
+	URL:  "https://www.drasov.cz/uredni-deska",
+}
+
+// ParseSource parses a source definition in the format "name=url".
+func ParseSource(s string) (Source, error) {
+	name, rawURL, ok := strings.Cut(s, "=")
+	if !ok || name == "" || rawURL == "" {
+		return Source{}, fmt.Errorf("unexpected source format, expected name=url: %s", s)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Source{}, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return Source{}, fmt.Errorf("source URL must be absolute: %s", rawURL)
+	}
+
+	return Source{Name: name, URL: rawURL}, nil
+}
+
+// allowedDomains returns the domains that are allowed to be visited when scraping the source,
+// which are the source host both with and without the "www." prefix.
+func (s Source) allowedDomains() ([]string, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, err
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	return []string{host, "www." + host}, nil
+}
+
+// ScraperConfig holds the configuration of the news scraper.
+type ScraperConfig struct {
+	// Sources are the news boards to scrape. If empty, DefaultSource is used.
+	Sources []Source
+	// Logger is used for logging of the scraping, e.g. of the visited URLs at the debug level.
+	// If nil, the default logger is used.
+	Logger *slog.Logger
+	// Debug enables colly's low-level debugging of the collectors, written to DebugOutput.
+	Debug bool
+	// DebugOutput is where the debugging output is written. If nil, it is written to stderr.
+	DebugOutput io.Writer
+	// Progress is called after each news entry of a source is scraped, with the number of scraped
+	// entries and the number of entries to scrape from the source's board, without the duplicates.
+	// With DetailThreads, it is called from multiple goroutines, but never concurrently.
+	Progress func(source Source, scraped, total int)
+	// NewestOnly limits the scraping to the most recently published news entry. Only the details
+	// page of that entry is visited, which makes the scraping much faster.
+	NewestOnly bool
+	// AttachmentExtensions are the file extensions, without the leading dot, of the attachments to keep,
+	// e.g. "pdf". If empty, all attachments are kept.
+	AttachmentExtensions []string
+	// Retry is the policy for retrying failed HTTP requests, e.g. due to network errors.
+	Retry RetryPolicy
+	// StartupRetry is the policy for retrying the fetch of the listing of a source, once it failed even after
+	// the retries of Retry, e.g. due to a longer network outage at the start of the scraping.
+	StartupRetry RetryPolicy
+	// RetryEmpty is the policy for fetching the listing again, if the board is present but contains
+	// no news entries, which happens occasionally due to glitches of the website. The listing is not
+	// fetched again, if it shows the placeholder of an empty board.
+	RetryEmpty RetryPolicy
+	// RawTitles disables the normalization of the news entry titles, see normalizeTitle.
+	RawTitles bool
+	// Delay is the delay between consecutive requests of a collector. With DetailThreads, each thread
+	// waits for the delay after its request, so up to DetailThreads details pages are fetched per delay.
+	Delay time.Duration
+	// RandomDelay is the maximum random delay added to Delay, to spread the requests in time.
+	RandomDelay time.Duration
+	// RequestTimeout is the timeout of a single HTTP request. If zero, colly's default is used.
+	// The whole scraping can be bounded by the context passed to Scraper.Scrape. If the context
+	// is done first, the in-flight request is cancelled regardless of its own timeout.
+	RequestTimeout time.Duration
+	// AllowedDomains are additional domains, besides the source host with and without the "www."
+	// prefix, which are allowed to be visited, e.g. when the website redirects to them.
+	AllowedDomains []string
+	// FollowExternalRedirects allows following redirects to any domain. Only the redirect targets
+	// are not restricted, the listing and details pages must still be on the allowed domains.
+	FollowExternalRedirects bool
+	// DetailThreads is the number of details pages fetched concurrently. Values lower than 1 mean
+	// a single thread, which fetches the details pages in the order of the listing.
+	DetailThreads int
+	// QueueSize is the maximum number of details page requests buffered in the queue. The details
+	// pages are queued in batches of this size. If zero, all details pages are queued at once.
+	QueueSize int
+	// CleanFilenames enables stripping of the file sizes from the attachment filenames,
+	// see CleanAttachmentFilename.
+	CleanFilenames bool
+	// EntryURLs are the URLs of the details pages of the news entries to scrape for each source, instead of
+	// its board listing. The news entries have only the data found on their details pages.
+	EntryURLs []string
+	// Transport is the base transport of all requests. If nil, a transport reusing the connections
+	// is created for the scraper, see MaxIdleConnsPerHost.
+	Transport http.RoundTripper
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept for reuse per host by the default
+	// Transport. If zero, at least 16 connections, or DetailThreads connections, are kept.
+	MaxIdleConnsPerHost int
+	// KeepRaw enables keeping the HTML of each news entry on the board listing, see NewsEntry.RawHTML.
+	KeepRaw bool
+	// MaxBodySize is the maximum size of a response body in bytes. Requests with a larger response fail
+	// with ErrBodyTooLarge, which fails the scraping of the listing, while the news entries with a larger
+	// details page are kept without their details. If zero, colly's default limit applies, which silently
+	// truncates the body.
+	MaxBodySize int
+	// InferUntil enables inferring the missing PublishedUntil date from the text of the details page,
+	// see InferPublishedUntil.
+	InferUntil bool
+}
+
+// ErrBoardNotFound is returned when the news board is not found on the scraped page.
+var ErrBoardNotFound = errors.New("news board not found")
+
+// errBoardEmpty is used to retry fetching of a board without any news entries.
+var errBoardEmpty = errors.New("news board contains no news entries")
+
+// emptyBoardSelector matches the placeholder that is rendered instead of the news board entries,
+// when the board has genuinely no entries. The class names are a guess following the BEM naming of the board,
+// they were not verified against the site, which has never been seen with an empty board.
+const emptyBoardSelector = ".c-office-board__empty, .c-office-board__no-items"
+
+// electronicOnlySelector matches the indicator of a board entry, which is published only electronically.
+// The indicator is either a modifier of the entry itself, or an icon within it. Like emptyBoardSelector,
+// the class names are a guess following the BEM naming of the board, unverified against the live board.
+const electronicOnlySelector = ".c-office-board__content-item--electronic, .c-office-board__icon-electronic"
+
+// Stats holds the statistics of a single scraping run.
+type Stats struct {
+	// Duration is the total duration of the scraping.
+	Duration time.Duration
+	// ListingPages is the number of fetched board listing pages.
+	ListingPages int
+	// DetailPages is the number of fetched news entry details pages.
+	DetailPages int
+	// Entries is the number of scraped news entries.
+	Entries int
+	// Attachments is the total number of attachments found.
+	Attachments int
+	// DuplicateEntries is the number of news entries found repeatedly on the listing, which were dropped.
+	DuplicateEntries int
+}
+
+// String returns a string representation of the statistics.
+func (s Stats) String() string {
+	return fmt.Sprintf("scraped in %s, fetched %d listing pages and %d details pages, found %d attachments, dropped %d duplicate entries",
+		s.Duration.Round(time.Millisecond), s.ListingPages, s.DetailPages, s.Attachments, s.DuplicateEntries)
+}
+
+// Scraper scrapes news entries from the configured sources.
+type Scraper struct {
+	config    ScraperConfig
+	transport http.RoundTripper
+	lastStats Stats
+	// visited are the URLs of the listings and details pages visited during the current scraping
+	visited *visitedURLs
+}
+
+// visitedURLs is a set of URLs, which is safe for concurrent use. It is shared by the collectors of all
+// sources, so that no URL is fetched twice within a single scraping, apart from the retries. For the details
+// pages, it keeps a news entry with the scraped details, so that they can be reused by the other sources.
+// The scraped boards are tracked separately, so that a board fetched as a details page of another source
+// is still scraped.
+type visitedURLs struct {
+	mu     sync.Mutex
+	urls   map[string]*NewsEntry
+	boards map[string]bool
+}
+
+// addBoard adds the URL of a board to the set and reports whether the board was not scraped yet,
+// even if the URL was already visited as a details page.
+func (v *visitedURLs) addBoard(url string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.boards[url] {
+		return false
+	}
+	if v.boards == nil {
+		v.boards = map[string]bool{}
+	}
+	v.boards[url] = true
+	if _, ok := v.urls[url]; !ok {
+		if v.urls == nil {
+			v.urls = map[string]*NewsEntry{}
+		}
+		v.urls[url] = nil
+	}
+	return true
+}
+
+// add adds the URL to the set and reports whether it was not in the set yet.
+func (v *visitedURLs) add(url string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.urls[url]; ok {
+		return false
+	}
+	if v.urls == nil {
+		v.urls = map[string]*NewsEntry{}
+	}
+	v.urls[url] = nil
+	return true
+}
+
+// setDetails records the news entry with the scraped details of the page with the given URL.
+func (v *visitedURLs) setDetails(url string, newsEntry *NewsEntry) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.urls[url] = newsEntry
+}
+
+// details returns the news entry with the scraped details of the page with the given URL, or nil if
+// the details were not scraped.
+func (v *visitedURLs) details(url string) *NewsEntry {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.urls[url]
+}
+
+// copyDetails copies the data scraped from the details page from one news entry to another,
+// keeping the data the other entry already has from its listing.
+func copyDetails(dst, src *NewsEntry) {
+	dst.Attachments = append([]NewsEntryAttachment(nil), src.Attachments...)
+	if dst.Title == "" {
+		dst.Title = src.Title
+	}
+	if dst.PublishedUntil == nil && src.PublishedUntilInferred {
+		dst.PublishedUntil = src.PublishedUntil
+		dst.PublishedUntilInferred = true
+	}
+	if dst.ReferenceNumber == "" {
+		dst.ReferenceNumber = src.ReferenceNumber
+	}
+	if dst.Authority == "" {
+		dst.Authority = src.Authority
+	}
+}
+
+// NewScraper returns a new scraper with the given configuration.
+func NewScraper(config ScraperConfig) *Scraper {
+	transport := config.Transport
+	if transport == nil {
+		maxIdleConnsPerHost := config.MaxIdleConnsPerHost
+		if maxIdleConnsPerHost <= 0 {
+			maxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+			if config.DetailThreads > maxIdleConnsPerHost {
+				maxIdleConnsPerHost = config.DetailThreads
+			}
+		}
+		// shared by all collectors and runs, so that the connections are reused
+		transport = newTransport(maxIdleConnsPerHost)
+	}
+	return &Scraper{config: config, transport: transport}
+}
+
+// logger returns the configured logger, or the default one.
+func (s *Scraper) logger() *slog.Logger {
+	if s.config.Logger != nil {
+		return s.config.Logger
+	}
+	return slog.Default()
+}
+
+// LastStats returns the statistics of the last scraping run.
+func (s *Scraper) LastStats() Stats {
+	return s.lastStats
+}
+
+// Scrape scrapes all news entries from all configured sources. The details pages are fetched concurrently,
+// see ScraperConfig.DetailThreads, but the entries are returned in the order in which they are listed
+// on the boards. When the context is done, the scraping is stopped and the context error is returned.
+func (s *Scraper) Scrape(ctx context.Context) (News, error) {
+	newsEntries, errs := s.ScrapeStream(ctx)
+
+	var news News
+	for newsEntry := range newsEntries {
+		news = append(news, newsEntry)
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	news = news.InDiscoveryOrder()
+
+	if s.config.NewestOnly {
+		news = news.Sorted().Limit(1)
+	}
+	return news, nil
+}
+
+// ScrapeStream scrapes all news entries from all configured sources, like Scrape, but sends each news entry
+// to the returned entries channel as soon as its details page is scraped. The entries channel is closed when
+// the scraping is done. Then, the errors channel receives the scraping error, if any, and is closed as well.
+// The entries channel must be drained, or the context cancelled, for the scraping to finish.
+//
+// With NewestOnly, the newest entry of each source is sent.
+func (s *Scraper) ScrapeStream(ctx context.Context) (<-chan *NewsEntry, <-chan error) {
+	newsEntries := make(chan *NewsEntry)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(newsEntries)
+
+		err := s.scrape(ctx, func(newsEntry *NewsEntry) {
+			select {
+			case newsEntries <- newsEntry:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return newsEntries, errs
+}
+
+// scrape scrapes all news entries from all configured sources and calls emit for each of them.
+func (s *Scraper) scrape(ctx context.Context, emit func(*NewsEntry)) error {
+	start := time.Now()
+	s.lastStats = Stats{}
+	s.visited = &visitedURLs{}
+	defer func() {
+		s.lastStats.Duration = time.Since(start)
+	}()
+
+	sources := s.config.Sources
+	if len(sources) == 0 {
+		sources = []Source{DefaultSource}
+	}
+
+	countingEmit := func(newsEntry *NewsEntry) {
+		s.lastStats.Entries++
+		emit(newsEntry)
+	}
+	for _, source := range sources {
+		err := s.scrapeSource(ctx, source, countingEmit)
+		if err != nil {
+			return fmt.Errorf("error while scraping source %s: %w", source.Name, err)
+		}
+	}
+	return nil
+}
+
+// ScrapeNewsEntries scrapes all news entries from all configured sources. It returns only after
+// the details pages of all news entries are scraped, or the scraping fails.
+func ScrapeNewsEntries(config ScraperConfig) (News, error) {
+	return NewScraper(config).Scrape(context.Background())
+}
+
+// scrapeSource scrapes all news entries from a single source and calls emit for each of them,
+// once the news entry details are scraped.
+func (s *Scraper) scrapeSource(ctx context.Context, source Source, emit func(*NewsEntry)) error {
+	config := s.config
+	logger := s.logger()
+
+	// map of news entries by their URL, multiple listing rows may point to the same URL
+	news := map[string][]*NewsEntry{}
+
+	// guards the state shared by the threads fetching the details pages
+	var mu sync.Mutex
+	// the first error, with which collecting of a details page failed
+	var detailsErr error
+
+	domains, err := source.allowedDomains()
+	if err != nil {
+		return err
+	}
+	options := []colly.CollectorOption{colly.AllowedDomains(append(domains, config.AllowedDomains...)...)}
+	if config.Debug {
+		output := config.DebugOutput
+		if output == nil {
+			output = os.Stderr
+		}
+		options = append(options, colly.Debugger(&debug.LogDebugger{Output: output}))
+	}
+
+	// the details pages are visited only once per URL, but the visit may be retried
+	detailsCollector := colly.NewCollector(append(options, colly.AllowURLRevisit())...)
+
+	detailsCollector.OnRequest(func(r *colly.Request) {
+		// the request URL is replaced by the redirect target, if the details page is redirected
+		r.Ctx.Put("entry_url", r.URL.String())
+	})
+
+	detailsCollector.OnResponse(func(r *colly.Response) {
+		mu.Lock()
+		defer mu.Unlock()
+		s.lastStats.DetailPages++
+	})
+
+	// the heading of the details page is the title of the news entries without a title on the listing
+	// or scraped without the listing, see ScraperConfig.EntryURLs
+	detailsCollector.OnHTML("h1", func(e *colly.HTMLElement) {
+		title := e.Text
+		if !config.RawTitles {
+			title = normalizeTitle(title)
+		}
+		for _, newsEntry := range news[e.Request.Ctx.Get("entry_url")] {
+			if newsEntry.Title == "" {
+				newsEntry.Title = title
+			}
+		}
+	})
+
+	detailsCollector.OnHTML(".c-card", func(e *colly.HTMLElement) {
+		entryURL := e.Request.Ctx.Get("entry_url")
+		newsEntries, ok := news[entryURL]
+		if !ok {
+			mu.Lock()
+			defer mu.Unlock()
+			if detailsErr == nil {
+				detailsErr = fmt.Errorf("news entry not found for URL %s", entryURL)
+			}
+			return
+		}
+
+		if config.InferUntil {
+			if until := InferPublishedUntil(e.Text); until != nil {
+				for _, newsEntry := range newsEntries {
+					if newsEntry.PublishedUntil == nil {
+						newsEntry.PublishedUntil = until
+						newsEntry.PublishedUntilInferred = true
+					}
+				}
+			}
+		}
+
+		if refNumber := findInTextElements(e, FindReferenceNumber); refNumber != "" {
+			for _, newsEntry := range newsEntries {
+				if newsEntry.ReferenceNumber == "" {
+					newsEntry.ReferenceNumber = refNumber
+				}
+			}
+		}
+
+		if authority := findInTextElements(e, FindAuthority); authority != "" {
+			for _, newsEntry := range newsEntries {
+				if newsEntry.Authority == "" {
+					newsEntry.Authority = authority
+				}
+			}
+		}
+
+		// extract attachments, the callback is called for each card on the page and the attachments
+		// of all cards accumulate, but the attachments of a nested card belong to the nested card only
+		card := e.DOM
+		e.ForEach(".c-files-wrapper", func(_ int, e *colly.HTMLElement) {
+			if !e.DOM.Closest(".c-card").IsSelection(card) {
+				return
+			}
+			attachment := NewsEntryAttachment{
+				Filename: e.ChildText("h3"),
+				URL:      e.ChildAttr("a", "href"),
+			}
+			if config.CleanFilenames {
+				attachment.Filename = CleanAttachmentFilename(attachment.Filename)
+			}
+			if len(config.AttachmentExtensions) > 0 && !hasExtension(attachment.URL, config.AttachmentExtensions) {
+				return
+			}
+			for _, newsEntry := range newsEntries {
+				newsEntry.Attachments = append(newsEntry.Attachments, attachment)
+			}
+			mu.Lock()
+			s.lastStats.Attachments++
+			mu.Unlock()
+		})
+	})
+
+	// URLs of the details pages, which are done, either scraped or dropped
+	detailsDone := map[string]bool{}
+	// number of news entries, whose details are scraped, and the number of all news entries to scrape
+	// from the board, without the duplicates
+	scraped, toScrape := 0, 0
+
+	detailsCollector.OnScraped(func(r *colly.Response) {
+		entryURL := r.Ctx.Get("entry_url")
+		mu.Lock()
+		defer mu.Unlock()
+		detailsDone[entryURL] = true
+		if newsEntries := news[entryURL]; len(newsEntries) > 0 {
+			s.visited.setDetails(entryURL, newsEntries[0])
+		}
+		for _, newsEntry := range news[entryURL] {
+			emit(newsEntry)
+			scraped++
+			if config.Progress != nil {
+				config.Progress(source, scraped, toScrape)
+			}
+		}
+	})
+
+	// the queue ignores the errors of the requests, so they are retried and recorded here
+	detailsCollector.OnError(func(r *colly.Response, err error) {
+		entryURL := r.Ctx.Get("entry_url")
+		if isDisallowedRedirect(err) || errors.Is(err, ErrBodyTooLarge) {
+			err = Permanent(err)
+		}
+		err = withRetryAfter(logger, r, err)
+		attempt, _ := r.Ctx.GetAny("attempt").(int)
+		attempt++
+		if err := config.Retry.wait(ctx, attempt, err); err != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			detailsDone[entryURL] = true
+			if isDisallowedRedirect(err) {
+				// already logged, the entries are dropped without their details
+				return
+			}
+			if errors.Is(err, ErrBodyTooLarge) {
+				// a single oversized details page doesn't fail the run, the entries are kept without their details
+				logger.Warn("keeping news entries without details, the details page is too large", "url", entryURL, "error", err)
+				for _, newsEntry := range news[entryURL] {
+					emit(newsEntry)
+					scraped++
+					if config.Progress != nil {
+						config.Progress(source, scraped, toScrape)
+					}
+				}
+				return
+			}
+			if detailsErr == nil && ctx.Err() == nil {
+				detailsErr = fmt.Errorf("error while collecting details from %s: %w", entryURL, err)
+			}
+			return
+		}
+		r.Ctx.Put("attempt", attempt)
+		// the failure of the retried request is handled by this callback as well
+		_ = r.Request.Retry()
+	})
+
+	// number of dropped duplicate news entries
+	duplicates := 0
+
+	// addNewsEntry adds the news entry scraped from the listing and queues its details page, unless it is
+	// already queued for another news entry
+	addNewsEntry := func(q *queue.Queue, newsEntry *NewsEntry) error {
+		if otherEntries, ok := news[newsEntry.EntryURL]; ok {
+			// the details are scraped only once for all news entries with the same URL
+			if !addCollidingNewsEntry(logger, news, otherEntries, newsEntry) {
+				duplicates++
+			} else if detailsDone[newsEntry.EntryURL] {
+				// the details were scraped in a previous batch
+				emit(newsEntry)
+				scraped++
+				if config.Progress != nil {
+					config.Progress(source, scraped, toScrape)
+				}
+			}
+			return nil
+		}
+
+		news[newsEntry.EntryURL] = []*NewsEntry{newsEntry}
+		if !s.visited.add(newsEntry.EntryURL) {
+			// e.g. the listing itself, or the details page of another source
+			logger.Debug("skipping details page, which was already visited", "url", newsEntry.EntryURL)
+			if details := s.visited.details(newsEntry.EntryURL); details != nil {
+				copyDetails(newsEntry, details)
+			}
+			detailsDone[newsEntry.EntryURL] = true
+			emit(newsEntry)
+			scraped++
+			if config.Progress != nil {
+				config.Progress(source, scraped, toScrape)
+			}
+			return nil
+		}
+		return q.AddURL(newsEntry.EntryURL)
+	}
+
+	// news entries found on the listing, whose details are visited only after the whole listing is scraped
+	var listedNews News
+
+	// the listing may be visited repeatedly, if it is empty or the visit is retried
+	allEntriesCollector := colly.NewCollector(append(options, colly.AllowURLRevisit())...)
+
+	threads := config.DetailThreads
+	if threads < 1 {
+		threads = 1
+	}
+
+	if config.Delay > 0 || config.RandomDelay > 0 {
+		// without the parallelism, colly makes a single request at a time, regardless of the threads
+		for c, parallelism := range map[*colly.Collector]int{detailsCollector: threads, allEntriesCollector: 1} {
+			err := c.Limit(&colly.LimitRule{
+				DomainGlob:  "*",
+				Delay:       config.Delay,
+				RandomDelay: config.RandomDelay,
+				Parallelism: parallelism,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, c := range []*colly.Collector{detailsCollector, allEntriesCollector} {
+		if config.FollowExternalRedirects {
+			// colly checks the redirect targets against the allowed domains in the client's
+			// CheckRedirect, so the client must be replaced to follow any redirect
+			jar, err := cookiejar.New(nil)
+			if err != nil {
+				return err
+			}
+			c.SetClient(&http.Client{
+				Jar:           jar,
+				Timeout:       10 * time.Second,
+				CheckRedirect: followRedirect(logger),
+			})
+		}
+		var transport http.RoundTripper = decompressingTransport{base: s.transport}
+		if config.MaxBodySize > 0 {
+			transport = bodyLimitTransport{base: transport, limit: int64(config.MaxBodySize)}
+			// colly silently truncates the body at its own limit, so it is set above the enforced one
+			c.MaxBodySize = config.MaxBodySize + 1
+		}
+		c.WithTransport(contextTransport{ctx: ctx, base: transport})
+		if config.RequestTimeout > 0 {
+			c.SetRequestTimeout(config.RequestTimeout)
+		}
+		c.OnRequest(func(r *colly.Request) {
+			if ctx.Err() != nil {
+				r.Abort()
+				return
+			}
+			logger.Debug("visiting", "url", r.URL.String())
+			r.Ctx.Put("start", time.Now())
+		})
+		c.OnResponse(func(r *colly.Response) {
+			attrs := []any{"url", r.Request.URL.String(), "status", r.StatusCode}
+			if start, ok := r.Ctx.GetAny("start").(time.Time); ok {
+				attrs = append(attrs, "duration", time.Since(start))
+			}
+			logger.Debug("fetched", attrs...)
+		})
+		c.OnError(func(r *colly.Response, err error) {
+			if isDisallowedRedirect(err) {
+				logger.Warn("redirect target is not allowed", "url", r.Request.URL.String(), "error", err)
+			}
+		})
+	}
+
+	allEntriesCollector.OnResponse(func(r *colly.Response) {
+		s.lastStats.ListingPages++
+	})
+
+	// the error of the last listing visit, with the delay requested by the server, if any
+	var listingErr error
+	allEntriesCollector.OnError(func(r *colly.Response, err error) {
+		listingErr = withRetryAfter(logger, r, err)
+	})
+
+	// whether the board is present on the listing and how many news entries it contains
+	boardFound := false
+	boardEmpty := false
+	boardEntries := 0
+
+	allEntriesCollector.OnHTML(emptyBoardSelector, func(e *colly.HTMLElement) {
+		boardFound = true
+		boardEmpty = true
+	})
+
+	allEntriesCollector.OnHTML(".c-office-board", func(e *colly.HTMLElement) {
+		total := e.DOM.Find(".c-office-board__content-item").Length()
+		boardFound = true
+		boardEntries += total
+
+		// iterate over all news entries
+		e.ForEach(".c-office-board__content-item", func(idx int, e *colly.HTMLElement) {
+			newsEntry := NewsEntry{Source: source.Name, Index: boardEntries - total + idx}
+			if config.KeepRaw {
+				if raw, err := goquery.OuterHtml(e.DOM); err == nil {
+					newsEntry.RawHTML = raw
+				}
+			}
+
+			// extract PublishedOn and PublishedUntil dates
+			if e.DOM.Find(".c-office-board__col-date").Length() == 0 {
+				logger.Warn("news entry has no date", "url", e.Request.URL.String(), "index", newsEntry.Index)
+			}
+			e.ForEach(".c-office-board__col-date", func(idx int, e *colly.HTMLElement) {
+				label, date, err := parseDateColumn(e.ChildTexts("span"))
+				if err != nil {
+					logger.Warn("skipping unparsable date of a news entry", "url", e.Request.URL.String(), "error", err)
+					return
+				}
+
+				switch dateColumnKindFromLabel(label, idx) {
+				case dateColumnPublishedOn:
+					newsEntry.PublishedOn = date
+				case dateColumnPublishedUntil:
+					newsEntry.PublishedUntil = date
+				default:
+					logger.Warn("skipping unexpected date column of a news entry", "url", e.Request.URL.String(), "label", label, "index", idx)
+				}
+			})
+
+			newsEntry.ElectronicOnly = e.DOM.Is(electronicOnlySelector) || e.DOM.Find(electronicOnlySelector).Length() > 0
+
+			// extract Category, which is not present on all boards
+			newsEntry.Category = normalizeTitle(e.ChildText(".c-office-board__col-type"))
+
+			// extract Title and EntryURL
+			e.ForEachWithBreak(".c-office-board__col-name-content", func(_ int, e *colly.HTMLElement) bool {
+				newsEntry.Title = e.ChildText("a")
+				if !config.RawTitles {
+					newsEntry.Title = normalizeTitle(newsEntry.Title)
+				}
+				newsEntry.EntryURL = e.Request.AbsoluteURL(e.ChildAttr("a", "href"))
+				return false
+			})
+			if e.DOM.Find(".c-office-board__col-name-content a").Length() == 0 {
+				logger.Warn("news entry has no title link", "url", e.Request.URL.String(), "index", newsEntry.Index)
+			} else if newsEntry.Title == "" {
+				logger.Warn("news entry has no title", "url", newsEntry.EntryURL)
+			}
+
+			// normalize the URL, so that it matches the URL of the visited details page
+			entryURL, err := newsEntry.URL()
+			if err != nil {
+				logger.Warn("skipping news entry with invalid URL", "title", newsEntry.Title, "error", err)
+				return
+			}
+			newsEntry.EntryURL = entryURL.String()
+
+			listedNews = append(listedNews, &newsEntry)
+		})
+	})
+
+	if len(config.EntryURLs) == 0 && !s.visited.addBoard(source.URL) {
+		logger.Warn("skipping the board, which was already scraped", "url", source.URL)
+		return nil
+	}
+
+	if len(config.EntryURLs) > 0 {
+		for idx, entryURL := range config.EntryURLs {
+			newsEntry := &NewsEntry{Source: source.Name, EntryURL: entryURL, Index: idx}
+			// normalize the URL, so that it matches the URL of the visited details page
+			u, err := newsEntry.URL()
+			if err != nil {
+				return err
+			}
+			newsEntry.EntryURL = u.String()
+			listedNews = append(listedNews, newsEntry)
+		}
+	} else {
+		err = config.RetryEmpty.Do(ctx, func() error {
+			boardFound, boardEmpty, boardEntries = false, false, 0
+			listedNews = nil
+			err := config.StartupRetry.Do(ctx, func() error {
+				err := config.Retry.Do(ctx, func() error {
+					listingErr = nil
+					err := allEntriesCollector.Visit(source.URL)
+					if errors.Is(err, ErrBodyTooLarge) {
+						return Permanent(err)
+					}
+					if err != nil && listingErr != nil {
+						return listingErr
+					}
+					return err
+				})
+				if errors.Is(err, ErrBodyTooLarge) {
+					return Permanent(err)
+				}
+				if err != nil {
+					logger.Debug("failed to fetch the listing", "url", source.URL, "error", err)
+				}
+				return err
+			})
+			if err != nil {
+				// logged only once all retries failed, so that -strict doesn't fail on a recovered fetch
+				logger.Warn("failed to fetch the listing", "url", source.URL, "error", err)
+				return Permanent(err)
+			}
+			// the listing collector is synchronous, so the visit is already complete and this is a no-op
+			allEntriesCollector.Wait()
+
+			if !boardFound {
+				return Permanent(ErrBoardNotFound)
+			}
+			if !boardEmpty && boardEntries == 0 {
+				logger.Info("found no news entries on the board", "url", source.URL)
+				return errBoardEmpty
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errBoardEmpty) {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if config.NewestOnly {
+		listedNews = listedNews.Sorted().Limit(1)
+	}
+
+	// the total is counted before the details are queued, so that it doesn't grow with each batch, the
+	// duplicates are the entries with the same URL and title, see addCollidingNewsEntry
+	listed := map[[2]string]bool{}
+	for _, newsEntry := range listedNews {
+		key := [2]string{newsEntry.EntryURL, newsEntry.Title}
+		if !listed[key] {
+			listed[key] = true
+			toScrape++
+		}
+	}
+
+	// the details pages are fetched only after the whole listing is scraped, in batches of the queue size
+	batchSize := config.QueueSize
+	if batchSize < 1 {
+		batchSize = len(listedNews)
+	}
+	for len(listedNews) > 0 {
+		q, err := queue.New(threads, &queue.InMemoryQueueStorage{MaxSize: batchSize})
+		if err != nil {
+			return err
+		}
+		for len(listedNews) > 0 {
+			if size, err := q.Size(); err != nil || size >= batchSize {
+				break
+			}
+			if err := addNewsEntry(q, listedNews[0]); err != nil {
+				return err
+			}
+			listedNews = listedNews[1:]
+		}
+		// Run returns only after all queued requests are completed, including their retries, which are
+		// made synchronously from the OnError callback of the thread that made the failed request
+		if err := q.Run(detailsCollector); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if detailsErr != nil {
+		return detailsErr
+	}
+	for entryURL := range news {
+		if !detailsDone[entryURL] {
+			return fmt.Errorf("details from %s were not collected", entryURL)
+		}
+	}
+
+	s.lastStats.DuplicateEntries += duplicates
+	if duplicates > 0 {
+		logger.Debug("dropped duplicate news entries", "url", source.URL, "count", duplicates)
+	}
+
+	return nil
+}
+
+// normalizeTitle unescapes any HTML entities left in the title, collapses all consecutive whitespace
+// into a single space and trims the leading and trailing whitespace.
+func normalizeTitle(title string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(title)), " ")
+}
+
+// withRetryAfter wraps the error of a response with the status 429 Too Many Requests, so that the request
+// is retried only after the delay requested by its Retry-After header, see RetryAfter.
+func withRetryAfter(logger *slog.Logger, r *colly.Response, err error) error {
+	if r == nil || r.StatusCode != http.StatusTooManyRequests || r.Headers == nil {
+		return err
+	}
+	delay, ok := ParseRetryAfter(r.Headers.Get("Retry-After"), time.Now())
+	if !ok {
+		return err
+	}
+	logger.Info("rate limited by the server", "url", r.Request.URL.String(), "retry_after", delay)
+	return RetryAfter(err, delay)
+}
+
+// isDisallowedRedirect reports whether the error is caused by a redirect to a domain, which is
+// not allowed. colly doesn't export the error, so it is recognized by its message.
+func isDisallowedRedirect(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "because its not in AllowedDomains")
+}
+
+// followRedirect returns a http.Client CheckRedirect function, which follows redirects to any domain,
+// up to the limit of the default http.Client.
+func followRedirect(logger *slog.Logger) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		lastRequest := via[len(via)-1]
+		if req.URL.Host != lastRequest.URL.Host {
+			logger.Debug("following external redirect", "from", lastRequest.URL.String(), "to", req.URL.String())
+			req.Header.Del("Authorization")
+		}
+		return nil
+	}
+}
+
+// attachmentSizeRe matches the size of the file appended to the displayed attachment filename,
+// e.g. " (1,2 MB)".
+var attachmentSizeRe = regexp.MustCompile(`\s*\(\s*[\d.,]+\s*[kKMG]?B\s*\)\s*$`)
+
+// CleanAttachmentFilename strips the trailing file size and the surrounding whitespace from the displayed
+// attachment filename, e.g. "Rozpočet 2024.pdf (1,2 MB)" becomes "Rozpočet 2024.pdf".
+func CleanAttachmentFilename(name string) string {
+	return strings.TrimSpace(attachmentSizeRe.ReplaceAllString(name, ""))
+}
+
+// textElements are the elements of a card, whose texts are searched one by one, so that a match
+// doesn't continue over the boundary of the element, e.g. into the next paragraph.
+const textElements = "p, li, dd, td"
+
+// findInTextElements returns the first non-empty result of find for the texts of the text elements
+// of the card, see textElements.
+func findInTextElements(card *colly.HTMLElement, find func(string) string) string {
+	var found string
+	card.ForEachWithBreak(textElements, func(_ int, e *colly.HTMLElement) bool {
+		found = find(e.Text)
+		return found == ""
+	})
+	return found
+}
+
+// hasExtension reports whether the path of the given URL ends with one of the given file extensions.
+// The extensions are compared case-insensitively and must not include the leading dot.
+func hasExtension(rawURL string, extensions []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	ext := strings.TrimPrefix(path.Ext(u.Path), ".")
+	for _, allowedExt := range extensions {
+		if strings.EqualFold(ext, allowedExt) {
+			return true
+		}
+	}
+	return false
+}
+
+// addCollidingNewsEntry adds a news entry whose URL is shared with already scraped entries.
+// The entry is considered a duplicate and dropped, if one of the other entries has the same title.
+// Otherwise, it is kept and gets the attachments from the shared details page.
+// It returns whether the entry was added.
+func addCollidingNewsEntry(logger *slog.Logger, news map[string][]*NewsEntry, otherEntries []*NewsEntry, newsEntry *NewsEntry) bool {
+	for _, otherEntry := range otherEntries {
+		if otherEntry.Title == newsEntry.Title {
+			logger.Warn("dropping duplicate news entry", "title", newsEntry.Title, "url", newsEntry.EntryURL)
+			return false
+		}
+	}
+
+	logger.Warn("news entries share the same URL", "title", newsEntry.Title, "other_title", otherEntries[0].Title, "url", newsEntry.EntryURL)
+	// the details may be already scraped, e.g. in a previous batch
+	copyDetails(newsEntry, otherEntries[0])
+	news[newsEntry.EntryURL] = append(otherEntries, newsEntry)
+	return true
+}
+
+// stringsFlag is a flag.Value that collects all values of a repeatable flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// errMissingUntil is returned by a run of main, which found news entries without the published until date
+// with -fail-on-missing-until.
+var errMissingUntil = errors.New("news entries without the published until date")
+
+// usageError prints the given error message followed by the usage and exits the program.
+func usageError(format string, a ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+	flag.Usage()
+	os.Exit(2)
+}
+
+// sourcesFlag is a flag.Value that collects all sources given by a repeatable flag.
+type sourcesFlag []Source
+
+func (s *sourcesFlag) String() string {
+	var sources []string
+	for _, source := range *s {
+		sources = append(sources, fmt.Sprintf("%s=%s", source.Name, source.URL))
+	}
+	return strings.Join(sources, ", ")
+}
+
+func (s *sourcesFlag) Set(value string) error {
+	source, err := ParseSource(value)
+	if err != nil {
+		return err
+	}
+	*s = append(*s, source)
+	return nil
+}
+
+// isTerminal reports whether the given file is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// atomicFile is a file, which is written as a temporary file in the same directory and renamed to its name
+// only when committed, so that the readers of the file never see it partially written.
+type atomicFile struct {
+	*os.File
+	name string
+}
+
+// createAtomicFile creates the temporary file for the file with the given name.
+func createAtomicFile(name string) (*atomicFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: f, name: name}, nil
+}
+
+// Commit flushes and closes the temporary file and renames it to the name of the file, replacing the file,
+// if it exists.
+func (f *atomicFile) Commit() error {
+	// the content must be on the disk before the rename, otherwise a crash may leave an empty file behind
+	err := f.Sync()
+	if err == nil {
+		// the temporary file is created readable only by its owner
+		err = f.Chmod(0o644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), f.name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Abort closes and removes the temporary file, keeping the file unchanged.
+func (f *atomicFile) Abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// outputError is returned by a run of main, which failed to write the output file given by -output.
+type outputError struct {
+	name string
+	err  error
+}
+
+func (e outputError) Error() string {
+	return fmt.Sprintf("failed to write the output to %s: %s", e.name, e.err)
+}
+
+func (e outputError) Unwrap() error {
+	return e.err
+}
+
+// progressPrinter prints the scraping progress to stderr, updating it in place.
+type progressPrinter struct {
+	printed bool
+}
+
+func (p *progressPrinter) Progress(source Source, scraped, total int) {
+	fmt.Fprintf(os.Stderr, "\r\033[KScraped %d/%d entries from %s", scraped, total, source.Name)
+	p.printed = true
+}
+
+// Done clears the progress line, if any was printed.
+func (p *progressPrinter) Done() {
+	if p.printed {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+}
+
+// warningRecorder is a slog.Handler, which records all warnings and errors before passing them
+// to the wrapped handler, so that -strict can fail the run because of them.
+type warningRecorder struct {
+	slog.Handler
+	warnings *recordedWarnings
+}
+
+// recordedWarnings are the warnings recorded by a warningRecorder and all handlers derived from it.
+type recordedWarnings struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (h warningRecorder) Handle(r slog.Record) error {
+	if r.Level >= slog.LevelWarn {
+		var sb strings.Builder
+		sb.WriteString(r.Message)
+		r.Attrs(func(a slog.Attr) {
+			sb.WriteString(" " + a.String())
+		})
+		h.warnings.mu.Lock()
+		h.warnings.messages = append(h.warnings.messages, sb.String())
+		h.warnings.mu.Unlock()
+	}
+	return h.Handler.Handle(r)
+}
+
+func (h warningRecorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return warningRecorder{Handler: h.Handler.WithAttrs(attrs), warnings: h.warnings}
+}
+
+func (h warningRecorder) WithGroup(name string) slog.Handler {
+	return warningRecorder{Handler: h.Handler.WithGroup(name), warnings: h.warnings}
+}
+
+// take returns the recorded warnings and forgets them.
+func (w *recordedWarnings) take() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	messages := w.messages
+	w.messages = nil
+	return messages
+}
+
+// strictError is returned by a run of main with -strict, which logged any warnings.
+type strictError struct {
+	warnings []string
+}
+
+func (e strictError) Error() string {
+	return "warnings logged in strict mode:\n  " + strings.Join(e.warnings, "\n  ")
+}
+
+func main() {
+	var excludeTitles stringsFlag
+	var sources sourcesFlag
+	var allowedDomains stringsFlag
+	var categories stringsFlag
+
+	minusDays := flag.Int("days", 30, "filter news entries published in the last N days")
+	verbose := flag.Bool("verbose", false, "enable debug logging, e.g. of the visited URLs")
+	logFormat := flag.String("log-format", "text", "format of the logs written to stderr, one of: text, json")
+	debugCollectors := flag.Bool("debug", false, "enable low-level debugging of HTTP requests and responses, printed to stderr")
+	flag.Var(&sources, "source", fmt.Sprintf("news board to scrape in the format name=url, can be repeated (default %s=%s)", DefaultSource.Name, DefaultSource.URL))
+	flag.Var(&excludeTitles, "exclude-title", "exclude news entries whose title contains the given text, case-insensitive (can be repeated)")
+	format := flag.String("format", "text", fmt.Sprintf("output format, one of: %s", strings.Join(RendererNames(), ", ")))
+	csvBOM := flag.Bool("csv-bom", false, "write the UTF-8 byte order mark at the start of the CSV output, so that Excel detects the encoding")
+	csvDelimiter := flag.String("csv-delimiter", ",", "field delimiter of the CSV output, e.g. \";\" for the Czech locale")
+	newestOnly := flag.Bool("newest-only", false, "scrape only the most recently published news entry, which is much faster")
+	attachmentExt := flag.String("attachment-ext", "", "keep only attachments with one of the given comma-separated file extensions, e.g. pdf,doc,docx")
+	retries := flag.Int("retries", 0, "retry failed HTTP requests up to N times")
+	retryBaseDelay := flag.Duration("retry-base-delay", time.Second, "delay before the first retry of a failed HTTP request, doubled with each retry")
+	startupRetries := flag.Int("startup-retries", 0, "fetch the board again up to N times, if fetching it failed even after -retries, e.g. due to a network outage")
+	startupRetryDelay := flag.Duration("startup-retry-delay", 5*time.Second, "delay before fetching the board again after a failure, doubled with each retry")
+	retryEmpty := flag.Int("retry-empty", 0, "fetch the board again up to N times, if it contains no news entries")
+	retryEmptyDelay := flag.Duration("retry-empty-delay", 10*time.Second, "delay before fetching the empty board again")
+	includeExpired := flag.Bool("include-expired", false, "include news entries whose published until date is before today, which are excluded by default")
+	requireUntil := flag.Bool("require-until", false, "print a warning for each news entry without a published until date")
+	failOnMissingUntil := flag.Bool("fail-on-missing-until", false, "exit with a nonzero status if any news entry has no published until date, implies -require-until")
+	rawTitles := flag.Bool("raw-titles", false, "keep the news entry titles as they are on the board, without normalizing whitespace and HTML entities")
+	noAttachments := flag.Bool("no-attachments", false, "filter news entries without any attachments")
+	delay := flag.Duration("delay", 0, "delay between consecutive requests")
+	randomDelay := flag.Duration("random-delay", 0, "maximum random delay added to -delay between consecutive requests")
+	withDate := flag.Bool("with-date", false, "prefix each title with the publication date in the titles output format")
+	requestTimeout := flag.Duration("request-timeout", 0, "timeout of a single HTTP request (default 10s)")
+	deadline := flag.Duration("deadline", 0, "maximum duration of the whole scraping, including all requests, delays and retries (0 means no deadline)")
+	flag.Var(&categories, "category", "filter news entries of the given category, case-insensitive (can be repeated to match any of the categories)")
+	validFor := flag.Duration("valid-for", 0, "filter news entries that stay published for at least the given duration from today, e.g. 168h")
+	minDisplayDuration := flag.Duration("min-display-duration", 0, "filter news entries published for at least the given duration from their posted on to their published until date, e.g. 720h")
+	attachmentName := flag.String("attachment-name", "", "filter news entries with an attachment whose filename matches the given shell pattern, case-insensitive, e.g. \"*rozpocet*.pdf\"")
+	limit := flag.Int("limit", 0, "output only the N most recently published news entries (0 means no limit)")
+	titleRegex := flag.String("title-regex", "", "filter news entries whose title matches the given regular expression (case-sensitive, unless using \"(?i)\")")
+	flag.Var(&allowedDomains, "allowed-domain", "additional domain allowed to be visited, e.g. as a redirect target (can be repeated)")
+	followExternalRedirects := flag.Bool("follow-external-redirects", false, "follow redirects to any domain, not only to the allowed domains")
+	detailThreads := flag.Int("detail-threads", 1, "number of details pages fetched concurrently")
+	queueSize := flag.Int("queue-size", 0, "maximum number of details pages queued at once (0 means no limit)")
+	inferUntil := flag.Bool("infer-until", false, "infer the missing published until date from the text of the details page, e.g. \"vyvěšeno do 15. 12. 2023\"")
+	urlPattern := flag.String("url-pattern", "", "filter news entries whose URL matches the given regular expression, e.g. \"/uredni-deska/vyhlasky/\"")
+	physicalOnly := flag.Bool("physical-only", false, "filter news entries physically posted on the notice board, excluding the electronic-only ones")
+	maxIdleConnsPerHost := flag.Int("max-idle-conns-per-host", 0, "maximum number of idle connections kept for reuse per host (0 means at least 16, or -detail-threads)")
+	maxBodySize := flag.Int("max-body-size", 10*1024*1024, "maximum size of a response body in bytes, larger responses fail the request")
+	notifyOnlyOnError := flag.Bool("notify-only-on-error", false, "print nothing if the run succeeds, otherwise print all diagnostics and exit with a nonzero status, e.g. for cron")
+	refContains := flag.String("ref-contains", "", "filter news entries whose reference number contains the given text, case-insensitive")
+	authorityContains := flag.String("authority-contains", "", "filter news entries whose issuing authority contains the given text, case-insensitive, e.g. \"stavební úřad\"")
+	printHash := flag.Bool("print-hash", false, "print only the content hash of the news entries instead of the -format output, which changes only if the entries change")
+	printStats := flag.Bool("stats", false, "print only the number of the news entries and their attachments on a single line instead of the -format output")
+	findDuplicates := flag.Bool("find-duplicates", false, "print only the news entries with the same title, e.g. posted twice by mistake, instead of the -format output")
+	groupAttachments := flag.Bool("group-attachments", false, "list the attachments with the same filename, e.g. in different formats, on a single line in the text output format")
+	dateBasis := flag.String("date-basis", "posted", "date to which -days applies, one of: posted (the posted on date), until (the published until date)")
+	fields := flag.String("fields", "", fmt.Sprintf("comma-separated fields written for each news entry in the text and csv output formats, any of: %s", strings.Join(EntryFields, ",")))
+	overlapDays := flag.Int("overlap-days", 0, "filter news entries published at any time within the last N days, even if posted earlier, instead of -days")
+	noSort := flag.Bool("no-sort", false, "output the news entries in the order in which they are listed on the board, instead of sorted by date")
+	weekday := flag.String("weekday", "", "filter news entries published on any of the given comma-separated days of the week, e.g. Mon,Tue")
+	cleanFilenames := flag.Bool("clean-filenames", false, "strip the file size, e.g. \"(1,2 MB)\", from the attachment filenames")
+	interval := flag.Duration("interval", 0, "scrape the boards right away and then repeatedly with the given interval, e.g. 1h, instead of only once")
+	asciiFilenames := flag.Bool("ascii-filenames", false, "transliterate the directory and file names in the aria2 output format to ASCII, e.g. \"Verejna vyzva.pdf\"")
+	keepRaw := flag.Bool("keep-raw", false, "keep the HTML of each news entry on the board, written by the debug output format")
+	headerText := flag.String("header-text", "", "line written before the news entries in the text output format, \"{count}\" and \"{date}\" are replaced by the number of the entries and the current date")
+	footerText := flag.String("footer-text", "", "line written after the news entries in the text output format, with the same placeholders as -header-text")
+	strict := flag.Bool("strict", false, "exit with a nonzero status and list the warnings, if any warning is logged, i.e. for a news entry "+
+		"without a date, with an unparsable date or with an unexpected date column, a news entry without a title link, without a title "+
+		"or with an invalid URL, a duplicate news entry, news entries sharing the same URL, a disallowed redirect, a too large details page, "+
+		"a fetch of the board failed even after all retries, skipping the board, which was already scraped, "+
+		"and for a news entry without a published until date with -require-until")
+	entryURL := flag.String("entry-url", "", "scrape only the details page with the given URL instead of the boards, e.g. to debug its parsing; "+
+		"the news entry has only the data from the details page and is not filtered by its dates")
+	output := flag.String("output", "", "write the output to the given file instead of stdout, replacing the file atomically only once the output is complete")
+	configFile := flag.String("config", "", "read the options from the given JSON file, mapping the flag names to their values; explicit flags take precedence")
+	flag.Parse()
+
+	if *configFile != "" {
+		if err := applyConfigFile(flag.CommandLine, *configFile); err != nil {
+			usageError("%s", err)
+		}
+	}
+
+	if *interval > 0 && *notifyOnlyOnError {
+		usageError("-interval can't be used with -notify-only-on-error")
+	}
+
+	// with -notify-only-on-error, the diagnostics are held back and written only if the run fails
+	var diagnostics io.Writer = os.Stderr
+	var heldDiagnostics bytes.Buffer
+	stdout := io.Writer(os.Stdout)
+	if *notifyOnlyOnError {
+		diagnostics = &heldDiagnostics
+		stdout = io.Discard
+	}
+	fail := func(format string, a ...any) {
+		os.Stderr.Write(heldDiagnostics.Bytes())
+		if format != "" {
+			fmt.Fprintf(os.Stderr, format+"\n", a...)
+		}
+		os.Exit(1)
+	}
+
+	handlerOptions := slog.HandlerOptions{Level: slog.LevelInfo}
+	if *verbose {
+		handlerOptions.Level = slog.LevelDebug
+	}
+	var logHandler slog.Handler
+	switch *logFormat {
+	case "text":
+		logHandler = handlerOptions.NewTextHandler(diagnostics)
+	case "json":
+		logHandler = handlerOptions.NewJSONHandler(diagnostics)
+	default:
+		usageError("unknown -log-format %q", *logFormat)
+	}
+	warnings := &recordedWarnings{}
+	if *strict {
+		logHandler = warningRecorder{Handler: logHandler, warnings: warnings}
+	}
+	slog.SetDefault(slog.New(logHandler))
+
+	if *dateBasis != "posted" && *dateBasis != "until" {
+		usageError("unknown -date-basis %q", *dateBasis)
+	}
+
+	if _, ok := LookupRenderer(*format); !ok {
+		usageError("unknown -format %q", *format)
+	}
+
+	delimiter, size := utf8.DecodeRuneInString(*csvDelimiter)
+	if size == 0 || size != len(*csvDelimiter) {
+		usageError("-csv-delimiter must be a single character, got %q", *csvDelimiter)
+	}
+	if !ValidCSVDelimiter(delimiter) {
+		usageError("-csv-delimiter must be a valid character other than a quote or a line break, got %q", *csvDelimiter)
+	}
+
+	var weekdays []time.Weekday
+	if *weekday != "" {
+		for _, name := range strings.Split(*weekday, ",") {
+			day, err := ParseWeekday(strings.TrimSpace(name))
+			if err != nil {
+				usageError("invalid -weekday: %s", err)
+			}
+			weekdays = append(weekdays, day)
+		}
+	}
+
+	var selectedFields []string
+	if *fields != "" {
+		for _, field := range strings.Split(*fields, ",") {
+			field = strings.TrimSpace(field)
+			if !slices.Contains(EntryFields, field) {
+				usageError("unknown field %q in -fields, valid fields are: %s", field, strings.Join(EntryFields, ", "))
+			}
+			selectedFields = append(selectedFields, field)
+		}
+	}
+
+	if _, err := filepath.Match(*attachmentName, ""); err != nil {
+		usageError("invalid -attachment-name pattern %q: %s", *attachmentName, err)
+	}
+
+	var titleRe *regexp.Regexp
+	if *titleRegex != "" {
+		var err error
+		titleRe, err = regexp.Compile(*titleRegex)
+		if err != nil {
+			usageError("invalid -title-regex pattern %q: %s", *titleRegex, err)
+		}
+	}
+
+	var urlRe *regexp.Regexp
+	if *urlPattern != "" {
+		var err error
+		urlRe, err = regexp.Compile(*urlPattern)
+		if err != nil {
+			usageError("invalid -url-pattern %q: %s", *urlPattern, err)
+		}
+	}
+
+	config := ScraperConfig{
+		Sources:     sources,
+		Debug:       *debugCollectors,
+		DebugOutput: diagnostics,
+		NewestOnly:  *newestOnly,
+		Retry: RetryPolicy{
+			MaxAttempts: *retries + 1,
+			BaseDelay:   *retryBaseDelay,
+			MaxDelay:    time.Minute,
+			Jitter:      true,
+		},
+		StartupRetry: RetryPolicy{
+			MaxAttempts: *startupRetries + 1,
+			BaseDelay:   *startupRetryDelay,
+			MaxDelay:    time.Minute,
+			Jitter:      true,
+		},
+		RetryEmpty: RetryPolicy{
+			MaxAttempts: *retryEmpty + 1,
+			BaseDelay:   *retryEmptyDelay,
+			MaxDelay:    *retryEmptyDelay,
+		},
+		RawTitles:               *rawTitles,
+		Delay:                   *delay,
+		RandomDelay:             *randomDelay,
+		RequestTimeout:          *requestTimeout,
+		AllowedDomains:          allowedDomains,
+		FollowExternalRedirects: *followExternalRedirects,
+		DetailThreads:           *detailThreads,
+		QueueSize:               *queueSize,
+		InferUntil:              *inferUntil,
+		MaxBodySize:             *maxBodySize,
+		MaxIdleConnsPerHost:     *maxIdleConnsPerHost,
+		CleanFilenames:          *cleanFilenames,
+		KeepRaw:                 *keepRaw,
+	}
+	if *entryURL != "" {
+		// the source is used only for the name and the allowed domain of the news entry
+		source := Source{Name: DefaultSource.Name, URL: *entryURL}
+		if len(sources) > 0 {
+			source.Name = sources[0].Name
+		}
+		config.Sources = []Source{source}
+		config.EntryURLs = []string{*entryURL}
+	}
+	for _, ext := range strings.Split(*attachmentExt, ",") {
+		ext = strings.TrimPrefix(strings.TrimSpace(ext), ".")
+		if ext != "" {
+			config.AttachmentExtensions = append(config.AttachmentExtensions, ext)
+		}
+	}
+
+	// the progress would get mixed with the other output or clutter the redirected stderr
+	progress := &progressPrinter{}
+	if !*verbose && !*debugCollectors && !*notifyOnlyOnError && isTerminal(os.Stderr) {
+		config.Progress = progress.Progress
+	}
+
+	scraper := NewScraper(config)
+	run := func() error {
+		ctx := context.Background()
+		if *deadline > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *deadline)
+			defer cancel()
+		}
+
+		news, err := scraper.Scrape(ctx)
+		progress.Done()
+		if err != nil {
+			return err
+		}
+		stats := scraper.LastStats()
+		slog.Debug("scraping finished",
+			"duration", stats.Duration,
+			"listing_pages", stats.ListingPages,
+			"detail_pages", stats.DetailPages,
+			"entries", stats.Entries,
+			"attachments", stats.Attachments,
+			"duplicate_entries", stats.DuplicateEntries,
+		)
+
+		sinceDate := NowDate().AddDate(0, 0, -*minusDays)
+		var filteredNews News
+		switch {
+		case *entryURL != "":
+			// the single news entry is kept regardless of its dates
+			filteredNews = news
+		case *overlapDays > 0:
+			filteredNews = news.OverlappingWindow(NowDate().AddDate(0, 0, -*overlapDays), NowDate())
+		case *dateBasis == "posted":
+			filteredNews = news.SinceIncluding(sinceDate)
+		case *dateBasis == "until":
+			filteredNews = news.ValidUntil(sinceDate)
+		}
+		// the board may still list entries that should have been already removed
+		if !*includeExpired && *entryURL == "" {
+			filteredNews = filteredNews.ExcludeExpired(NowDate())
+		}
+		for _, excludeTitle := range excludeTitles {
+			filteredNews = filteredNews.ExcludeTitle(excludeTitle, true)
+		}
+		if titleRe != nil {
+			filteredNews = filteredNews.FilterByTitleRegex(titleRe)
+		}
+		if urlRe != nil {
+			filteredNews = filteredNews.FilterByURLPattern(urlRe)
+		}
+		if *refContains != "" {
+			filteredNews = filteredNews.FilterByReferenceNumber(*refContains)
+		}
+		if *authorityContains != "" {
+			filteredNews = filteredNews.FilterByAuthority(*authorityContains)
+		}
+		if len(weekdays) > 0 {
+			filteredNews = filteredNews.FilterByWeekday(weekdays...)
+		}
+		if *physicalOnly {
+			filteredNews = filteredNews.PhysicallyPosted()
+		}
+		if *validFor > 0 {
+			filteredNews = filteredNews.ValidUntil(NowDate().Add(*validFor))
+		}
+		if *minDisplayDuration > 0 {
+			filteredNews = filteredNews.FilterByDisplayDuration(*minDisplayDuration)
+		}
+		if len(categories) > 0 {
+			filteredNews = filteredNews.FilterByCategories(categories...)
+		}
+		if *attachmentName != "" {
+			filteredNews = filteredNews.FilterByAttachmentName(*attachmentName)
+		}
+		if *noAttachments {
+			filteredNews = filteredNews.WithoutAttachments()
+		}
+
+		if *noSort {
+			filteredNews = filteredNews.InDiscoveryOrder()
+		} else {
+			filteredNews = filteredNews.Sorted()
+		}
+		if *limit > 0 {
+			filteredNews = filteredNews.Limit(*limit)
+		}
+
+		textRenderer := TextRenderer{GroupAttachments: *groupAttachments, Fields: selectedFields, Header: *headerText, Footer: *footerText}
+		if *dateBasis == "posted" && *overlapDays <= 0 && *entryURL == "" {
+			textRenderer.Since = sinceDate
+		}
+		// the built-in renderers are configured by the flags, while any other renderer registered
+		// for the format is used as it is
+		renderer, _ := LookupRenderer(*format)
+		switch renderer.(type) {
+		case TextRenderer:
+			renderer = textRenderer
+		case CSVRenderer:
+			renderer = CSVRenderer{Options: CSVOptions{Delimiter: delimiter, BOM: *csvBOM, Fields: selectedFields}}
+		case TitlesRenderer:
+			renderer = TitlesRenderer{WithDate: *withDate}
+		case SummaryRenderer:
+			renderer = SummaryRenderer{At: NowDate()}
+		case Aria2Renderer:
+			renderer = Aria2Renderer{Options: Aria2Options{ASCIIFilenames: *asciiFilenames}}
+		}
+
+		out := stdout
+		if *output != "" {
+			f, err := createAtomicFile(*output)
+			if err != nil {
+				return outputError{name: *output, err: err}
+			}
+			defer f.Abort()
+			out = f
+		}
+
+		switch {
+		case *printHash:
+			fmt.Fprintln(out, filteredNews.ContentHash())
+		case *findDuplicates:
+			duplicates := filteredNews.DuplicateTitles()
+			titles := maps.Keys(duplicates)
+			slices.Sort(titles)
+			for _, title := range titles {
+				fmt.Fprintf(out, "%s (%d entries)\n", duplicates[title][0].Title, len(duplicates[title]))
+				for _, newsEntry := range duplicates[title] {
+					fmt.Fprintf(out, "  %s\n", newsEntry.EntryURL)
+				}
+			}
+		case *printStats:
+			fmt.Fprintf(out, "%d entries, %d attachments\n", filteredNews.EntryCount(), filteredNews.TotalAttachments())
+		default:
+			if err := renderer.Render(out, filteredNews); err != nil {
+				if *output != "" {
+					return outputError{name: *output, err: err}
+				}
+				return err
+			}
+		}
+		if f, ok := out.(*atomicFile); ok {
+			if err := f.Commit(); err != nil {
+				return outputError{name: *output, err: err}
+			}
+		}
+
+		if *requireUntil || *failOnMissingUntil {
+			missingUntil := filteredNews.MissingPublishedUntil()
+			for _, newsEntry := range missingUntil {
+				slog.Warn("news entry has no published until date", "title", newsEntry.Title, "url", newsEntry.EntryURL)
+			}
+			if *failOnMissingUntil && len(missingUntil) > 0 {
+				return errMissingUntil
+			}
+		}
+		if recorded := warnings.take(); len(recorded) > 0 {
+			return strictError{warnings: recorded}
+		}
+		return nil
+	}
+
+	if *interval <= 0 {
+		switch err := run(); {
+		case err == nil:
+		case errors.Is(err, errMissingUntil):
+			fail("")
+		default:
+			// e.g. an expired -deadline or a missing board, which are expected outcomes, not bugs
+			fail("error: %s", err)
+		}
+		return
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	repeat(context.Background(), ticker.C, run)
+}
+
+// repeat calls run right away, not only after the first tick, and then on each tick, until the context
+// is done. The errors of run are logged, except for errMissingUntil, whose entries are already logged.
+func repeat(ctx context.Context, ticks <-chan time.Time, run func() error) {
+	for {
+		if err := run(); err != nil && !errors.Is(err, errMissingUntil) {
+			slog.Error("scraping failed", err)
+		}
+		select {
+		case <-ticks:
+		case <-ctx.Done():
+			return
+		}
+	}
 }
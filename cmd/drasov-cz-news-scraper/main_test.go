@@ -0,0 +1,1227 @@
+/*
+ * www.drasov.cz/uredni-deska news scraper
+ *
+ * Copyright (C) 2023  Tomáš Hozza
+ */
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// boardServer serves fixture pages, keyed by their path, and counts the requests of each path.
+type boardServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	pages    map[string]string
+	requests map[string]int
+}
+
+// newBoardServer starts a server serving the given pages, which is closed at the end of the test.
+func newBoardServer(t *testing.T, pages map[string]string) *boardServer {
+	t.Helper()
+	s := &boardServer{pages: pages, requests: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		page, ok := s.pages[r.URL.Path]
+		s.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// requestCount returns the number of requests of the given path.
+func (s *boardServer) requestCount(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// source returns the source of the board served at the given path.
+func (s *boardServer) source(path string) Source {
+	return Source{Name: "test", URL: s.URL + path}
+}
+
+// boardPage returns a board listing page with the given entries, see boardItem.
+func boardPage(items ...string) string {
+	return `<html><body><div class="c-office-board">` + strings.Join(items, "") + `</div></body></html>`
+}
+
+// boardItem returns a board entry linking to the given details page, with the given date columns,
+// each given as the HTML of the column's spans, see dateColumn.
+func boardItem(href, title string, dateColumns ...string) string {
+	var sb strings.Builder
+	sb.WriteString(`<div class="c-office-board__content-item">`)
+	fmt.Fprintf(&sb, `<div class="c-office-board__col-name-content"><a href="%s">%s</a></div>`, href, title)
+	for _, column := range dateColumns {
+		fmt.Fprintf(&sb, `<div class="c-office-board__col-date">%s</div>`, column)
+	}
+	sb.WriteString(`</div>`)
+	return sb.String()
+}
+
+// dateColumn returns the spans of a date column with the given label and date.
+func dateColumn(label, date string) string {
+	return fmt.Sprintf("<span>%s</span><span>%s</span>", label, date)
+}
+
+// detailsPage returns a details page with the given cards, see card.
+func detailsPage(cards ...string) string {
+	return `<html><body>` + strings.Join(cards, "") + `</body></html>`
+}
+
+// card returns a card with the given content followed by the given attachments, each given as
+// a filename and an URL.
+func card(content string, attachments ...[2]string) string {
+	var sb strings.Builder
+	sb.WriteString(`<div class="c-card">` + content)
+	for _, attachment := range attachments {
+		fmt.Fprintf(&sb, `<div class="c-files-wrapper"><h3>%s</h3><a href="%s">Stáhnout</a></div>`, attachment[0], attachment[1])
+	}
+	sb.WriteString(`</div>`)
+	return sb.String()
+}
+
+// isoDate returns the pointer to the given ISO date, e.g. "2026-10-01", which is then midnight in UTC.
+func isoDate(t *testing.T, date string) *time.Time {
+	t.Helper()
+	d, err := time.Parse(isoDateFormat, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &d
+}
+
+// entryURLs returns the URLs of the news entries in their order.
+func entryURLs(n News) []string {
+	var urls []string
+	for _, newsEntry := range n {
+		urls = append(urls, newsEntry.EntryURL)
+	}
+	return urls
+}
+
+// testLogger returns a logger writing to the returned buffer, which can be inspected after the scraping.
+func testLogger() (*slog.Logger, *bytes.Buffer) {
+	var logs bytes.Buffer
+	return slog.New(slog.HandlerOptions{Level: slog.LevelDebug}.NewTextHandler(&logs)), &logs
+}
+
+// scrape scrapes the sources of the config with a test logger and fails the test on an error.
+func scrape(t *testing.T, config ScraperConfig) (News, *bytes.Buffer) {
+	t.Helper()
+	logger, logs := testLogger()
+	config.Logger = logger
+	news, err := NewScraper(config).Scrape(context.Background())
+	if err != nil {
+		t.Fatalf("scraping failed: %s\nlogs:\n%s", err, logs)
+	}
+	return news, logs
+}
+
+func TestScrapeSkipsUnexpectedDateColumn(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska": boardPage(boardItem("/uredni-deska/a", "Vyhláška",
+			dateColumn("Vyvěšeno", "1. 10. 2026"),
+			dateColumn("Sejmuto", "30. 10. 2026"),
+			"<span>2. 10. 2026</span>",
+		)),
+		"/uredni-deska/a": detailsPage(card("<p>Text</p>")),
+	})
+
+	news, logs := scrape(t, ScraperConfig{Sources: []Source{server.source("/uredni-deska")}})
+	if len(news) != 1 {
+		t.Fatalf("expected 1 news entry, got %d", len(news))
+	}
+	if got := formatISODate(news[0].PublishedOn); got != "2026-10-01" {
+		t.Errorf("expected published on 2026-10-01, got %s", got)
+	}
+	if got := formatISODate(news[0].PublishedUntil); got != "2026-10-30" {
+		t.Errorf("expected published until 2026-10-30, got %s", got)
+	}
+	if !strings.Contains(logs.String(), "skipping unexpected date column") {
+		t.Errorf("expected a warning about the unexpected date column, got logs:\n%s", logs)
+	}
+}
+
+func TestNewsMerge(t *testing.T) {
+	older := &NewsEntry{Title: "Starší", EntryURL: "/a", PublishedOn: isoDate(t, "2026-09-01")}
+	updated := &NewsEntry{Title: "Aktualizovaná", EntryURL: "/b", PublishedOn: isoDate(t, "2026-10-02")}
+	outdated := &NewsEntry{Title: "Původní", EntryURL: "/b", PublishedOn: isoDate(t, "2026-10-01")}
+	newer := &NewsEntry{Title: "Novější", EntryURL: "/c", PublishedOn: isoDate(t, "2026-10-05")}
+
+	merged := News{older, updated}.Merge(News{outdated, newer})
+
+	if got, want := strings.Join(entryURLs(merged), ","), "/c,/b,/a"; got != want {
+		t.Fatalf("expected the URLs %s, got %s", want, got)
+	}
+	if merged[1] != updated {
+		t.Errorf("expected the entry of the receiver to take precedence, got %q", merged[1].Title)
+	}
+}
+
+func TestScrapeEntriesSharingURL(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska": boardPage(
+			boardItem("/uredni-deska/a", "Veřejná vyhláška", dateColumn("Vyvěšeno", "1. 10. 2026")),
+			boardItem("/uredni-deska/a", "Oprava veřejné vyhlášky", dateColumn("Vyvěšeno", "2. 10. 2026")),
+			boardItem("/uredni-deska/a", "Veřejná vyhláška", dateColumn("Vyvěšeno", "3. 10. 2026")),
+		),
+		"/uredni-deska/a": detailsPage(card("<p>Text</p>", [2]string{"Vyhláška.pdf", "/files/a.pdf"})),
+	})
+
+	scraper := NewScraper(ScraperConfig{Sources: []Source{server.source("/uredni-deska")}})
+	logger, logs := testLogger()
+	scraper.config.Logger = logger
+	news, err := scraper.Scrape(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(news) != 2 {
+		t.Fatalf("expected 2 news entries, got %d:\n%s", len(news), news)
+	}
+	for _, newsEntry := range news {
+		if len(newsEntry.Attachments) != 1 {
+			t.Errorf("expected the attachment of the shared details page for %q, got %v", newsEntry.Title, newsEntry.Attachments)
+		}
+	}
+	if got := server.requestCount("/uredni-deska/a"); got != 1 {
+		t.Errorf("expected the shared details page to be fetched once, got %d requests", got)
+	}
+	if got := scraper.LastStats().DuplicateEntries; got != 1 {
+		t.Errorf("expected 1 dropped duplicate entry, got %d", got)
+	}
+	if !strings.Contains(logs.String(), "news entries share the same URL") {
+		t.Errorf("expected a warning about the shared URL, got logs:\n%s", logs)
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"  Veřejná   vyhláška &amp; oznámení ", "Veřejná vyhláška & oznámení"},
+		{"Pozvánka\n\tna zasedání", "Pozvánka na zasedání"},
+		{"Oznámení&nbsp;o&nbsp;výběrovém řízení", "Oznámení o výběrovém řízení"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTitle(tt.title); got != tt.want {
+			t.Errorf("normalizeTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestScrapeEmptyBoard(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska": `<html><body><div class="c-office-board"><p class="c-office-board__empty">Žádné záznamy</p></div></body></html>`,
+	})
+
+	scraper := NewScraper(ScraperConfig{
+		Sources:    []Source{server.source("/uredni-deska")},
+		RetryEmpty: RetryPolicy{MaxAttempts: 3},
+	})
+	news, err := scraper.Scrape(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(news) != 0 {
+		t.Errorf("expected no news entries, got %d", len(news))
+	}
+	// the placeholder means the board is genuinely empty, so it is not fetched again
+	if got := server.requestCount("/uredni-deska"); got != 1 {
+		t.Errorf("expected the empty board to be fetched once, got %d requests", got)
+	}
+}
+
+func TestScrapeMissingBoard(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska": `<html><body><p>Stránka se připravuje</p></body></html>`,
+	})
+
+	_, err := NewScraper(ScraperConfig{Sources: []Source{server.source("/uredni-deska")}}).Scrape(context.Background())
+	if !errors.Is(err, ErrBoardNotFound) {
+		t.Errorf("expected ErrBoardNotFound, got %v", err)
+	}
+}
+
+// transportTo returns a transport, which connects to the test server for all hosts, so that the server
+// can simulate several domains.
+func transportTo(server *httptest.Server) http.RoundTripper {
+	addr := server.Listener.Addr().String()
+	return &http.Transport{DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, addr)
+	}}
+}
+
+func TestScrapeRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Host + r.URL.Path {
+		case "www.drasov.test/uredni-deska":
+			fmt.Fprint(w, boardPage(
+				boardItem("/uredni-deska/a", "Vyhláška", dateColumn("Vyvěšeno", "1. 10. 2026")),
+				boardItem("/uredni-deska/b", "Pozvánka", dateColumn("Vyvěšeno", "2. 10. 2026")),
+			))
+		case "www.drasov.test/uredni-deska/a":
+			http.Redirect(w, r, "http://drasov.test/uredni-deska/a", http.StatusMovedPermanently)
+		case "www.drasov.test/uredni-deska/b":
+			http.Redirect(w, r, "http://files.example.test/b", http.StatusFound)
+		case "drasov.test/uredni-deska/a":
+			fmt.Fprint(w, detailsPage(card("", [2]string{"Vyhláška.pdf", "/files/a.pdf"})))
+		case "files.example.test/b":
+			fmt.Fprint(w, detailsPage(card("", [2]string{"Pozvánka.pdf", "/files/b.pdf"})))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		allowedDomains []string
+		followAll      bool
+		wantTitles     string
+		wantWarning    bool
+	}{
+		{name: "www and non-www only", wantTitles: "Vyhláška", wantWarning: true},
+		{name: "extra allowed domain", allowedDomains: []string{"files.example.test"}, wantTitles: "Pozvánka,Vyhláška"},
+		{name: "external redirects", followAll: true, wantTitles: "Pozvánka,Vyhláška"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			news, logs := scrape(t, ScraperConfig{
+				Sources:                 []Source{{Name: "test", URL: "http://www.drasov.test/uredni-deska"}},
+				Transport:               transportTo(server),
+				AllowedDomains:          tt.allowedDomains,
+				FollowExternalRedirects: tt.followAll,
+			})
+
+			var titles []string
+			for _, newsEntry := range news.Sorted() {
+				titles = append(titles, newsEntry.Title)
+				if len(newsEntry.Attachments) != 1 {
+					t.Errorf("expected the attachment of %q from the redirect target, got %v", newsEntry.Title, newsEntry.Attachments)
+				}
+			}
+			if got := strings.Join(titles, ","); got != tt.wantTitles {
+				t.Errorf("expected the news entries %s, got %s", tt.wantTitles, got)
+			}
+			if got := strings.Contains(logs.String(), "redirect target is not allowed"); got != tt.wantWarning {
+				t.Errorf("expected the warning about the disallowed redirect to be logged: %t, got logs:\n%s", tt.wantWarning, logs)
+			}
+		})
+	}
+}
+
+func TestScrapeAttachmentsOfAllCards(t *testing.T) {
+	nested := card("<p>Příloha</p>", [2]string{"Mapa.pdf", "/files/mapa.pdf"})
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska": boardPage(boardItem("/uredni-deska/a", "Pozvánka", dateColumn("Vyvěšeno", "1. 10. 2026"))),
+		"/uredni-deska/a": detailsPage(
+			card("<p>Text</p>", [2]string{"Pozvánka.pdf", "/files/pozvanka.pdf"}),
+			card(nested, [2]string{"Program.docx", "/files/program.docx"}),
+		),
+	})
+
+	news, _ := scrape(t, ScraperConfig{Sources: []Source{server.source("/uredni-deska")}})
+	if len(news) != 1 {
+		t.Fatalf("expected 1 news entry, got %d", len(news))
+	}
+	var urls []string
+	for _, attachment := range news[0].Attachments {
+		urls = append(urls, attachment.URL)
+	}
+	// each attachment is found once, the ones of the nested card after the ones of its parent card
+	want := "/files/pozvanka.pdf,/files/program.docx,/files/mapa.pdf"
+	if got := strings.Join(urls, ","); got != want {
+		t.Errorf("expected the attachments %s, got %s", want, got)
+	}
+}
+
+func TestNewsPartition(t *testing.T) {
+	at := *isoDate(t, "2026-10-14")
+	tests := []struct {
+		name  string
+		on    string
+		until string
+		want  string
+	}{
+		{name: "active", on: "2026-10-01", until: "2026-10-30", want: "active"},
+		{name: "posted today", on: "2026-10-14", until: "2026-10-30", want: "active"},
+		{name: "posted tomorrow", on: "2026-10-15", until: "2026-10-30", want: "upcoming"},
+		{name: "expires today", on: "2026-10-01", until: "2026-10-14", want: "active"},
+		{name: "expired yesterday", on: "2026-10-01", until: "2026-10-13", want: "expired"},
+		{name: "posted and expiring today", on: "2026-10-14", until: "2026-10-14", want: "active"},
+		{name: "unknown dates", want: "active"},
+		{name: "unknown posted on", until: "2026-10-13", want: "expired"},
+		{name: "unknown published until", on: "2026-10-15", want: "upcoming"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newsEntry := &NewsEntry{Title: tt.name}
+			if tt.on != "" {
+				newsEntry.PublishedOn = isoDate(t, tt.on)
+			}
+			if tt.until != "" {
+				newsEntry.PublishedUntil = isoDate(t, tt.until)
+			}
+
+			upcoming, active, expired := News{newsEntry}.Partition(at)
+			got := map[string]int{"upcoming": len(upcoming), "active": len(active), "expired": len(expired)}
+			for state, count := range got {
+				if want := map[bool]int{true: 1}[state == tt.want]; count != want {
+					t.Errorf("expected %d %s entries, got %d", want, state, count)
+				}
+			}
+		})
+	}
+}
+
+// largeBoard returns the pages of a board with the given number of news entries, whose details pages,
+// "/uredni-deska/<i>", have the given number of attachments each.
+func largeBoard(entries, attachments int) map[string]string {
+	pages := map[string]string{}
+	var items []string
+	for i := 0; i < entries; i++ {
+		href := fmt.Sprintf("/uredni-deska/%d", i)
+		items = append(items, boardItem(href, fmt.Sprintf("Oznámení %d", i), dateColumn("Vyvěšeno", "1. 10. 2026")))
+		var files [][2]string
+		for j := 0; j < attachments; j++ {
+			files = append(files, [2]string{fmt.Sprintf("Příloha %d.pdf", j), fmt.Sprintf("/files/%d-%d.pdf", i, j)})
+		}
+		pages[href] = detailsPage(card("<p>Text</p>", files...))
+	}
+	pages["/uredni-deska"] = boardPage(items...)
+	return pages
+}
+
+func TestScrapeDelayKeepsDetailThreads(t *testing.T) {
+	pages := largeBoard(8, 0)
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, pages[r.URL.Path])
+	}))
+	defer server.Close()
+
+	scrape(t, ScraperConfig{
+		Sources:       []Source{{Name: "test", URL: server.URL + "/uredni-deska"}},
+		Delay:         10 * time.Millisecond,
+		DetailThreads: 4,
+	})
+	if maxInFlight < 2 {
+		t.Errorf("expected the details pages to be fetched concurrently with a delay, got at most %d concurrent requests", maxInFlight)
+	}
+	if maxInFlight > 4 {
+		t.Errorf("expected at most 4 concurrent requests, got %d", maxInFlight)
+	}
+}
+
+func TestScrapeProgressTotal(t *testing.T) {
+	pages := largeBoard(29, 0)
+	// a duplicate of the first entry is not counted in the total
+	pages["/uredni-deska"] = strings.Replace(pages["/uredni-deska"], `</div></body>`,
+		boardItem("/uredni-deska/0", "Oznámení 0", dateColumn("Vyvěšeno", "1. 10. 2026"))+`</div></body>`, 1)
+	server := newBoardServer(t, pages)
+
+	var updates [][2]int
+	news, _ := scrape(t, ScraperConfig{
+		Sources:   []Source{server.source("/uredni-deska")},
+		QueueSize: 7,
+		Progress: func(source Source, scraped, total int) {
+			updates = append(updates, [2]int{scraped, total})
+		},
+	})
+	if len(news) != 29 {
+		t.Fatalf("expected 29 news entries, got %d", len(news))
+	}
+	if len(updates) != 29 {
+		t.Fatalf("expected 29 progress updates, got %d", len(updates))
+	}
+	for i, update := range updates {
+		if update != [2]int{i + 1, 29} {
+			t.Errorf("expected progress %d/29, got %d/%d", i+1, update[0], update[1])
+		}
+	}
+}
+
+func TestScrapeInferPublishedUntil(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska": boardPage(
+			boardItem("/uredni-deska/1", "Oznámení", dateColumn("Vyvěšeno", "1. 10. 2026")),
+			boardItem("/uredni-deska/2", "Vyhláška", dateColumn("Vyvěšeno", "1. 10. 2026"), dateColumn("Sejmuto", "15. 10. 2026")),
+		),
+		"/uredni-deska/1": detailsPage(card("<p>Oznámení je vyvěšeno do 31. 10. 2026 na úřední desce.</p>")),
+		"/uredni-deska/2": detailsPage(card("<p>Vyhláška je vyvěšena do 31. 10. 2026.</p>")),
+	})
+
+	news, _ := scrape(t, ScraperConfig{
+		Sources:    []Source{server.source("/uredni-deska")},
+		InferUntil: true,
+	})
+	byURL := map[string]*NewsEntry{}
+	for _, newsEntry := range news {
+		byURL[strings.TrimPrefix(newsEntry.EntryURL, server.URL)] = newsEntry
+	}
+
+	inferred := byURL["/uredni-deska/1"]
+	if inferred.PublishedUntil == nil || !inferred.PublishedUntil.Equal(*isoDate(t, "2026-10-31")) || !inferred.PublishedUntilInferred {
+		t.Errorf("expected the inferred PublishedUntil 2026-10-31, got %v (inferred: %t)", inferred.PublishedUntil, inferred.PublishedUntilInferred)
+	}
+	// the date from the board takes precedence over the text
+	listed := byURL["/uredni-deska/2"]
+	if listed.PublishedUntil == nil || !listed.PublishedUntil.Equal(*isoDate(t, "2026-10-15")) || listed.PublishedUntilInferred {
+		t.Errorf("expected the listed PublishedUntil 2026-10-15, got %v (inferred: %t)", listed.PublishedUntil, listed.PublishedUntilInferred)
+	}
+}
+
+func TestInferPublishedUntil(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Vyvěšeno do 15. 12. 2023", "2023-12-15"},
+		{"Do 1.2.2024 lze podat připomínky.", "2024-02-01"},
+		{"Vyvěšeno od 1. 12. 2023", ""},
+		{"Platí dodnes 15. 12. 2023", ""},
+		{"Vyvěšeno do 31. 2. 202", ""},
+	}
+	for _, tt := range tests {
+		got := InferPublishedUntil(tt.text)
+		switch {
+		case tt.want == "" && got != nil:
+			t.Errorf("InferPublishedUntil(%q) = %v, want nil", tt.text, got)
+		case tt.want != "" && (got == nil || !got.Equal(*isoDate(t, tt.want))):
+			t.Errorf("InferPublishedUntil(%q) = %v, want %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestScrapeGzipEncodedBoard(t *testing.T) {
+	pages := map[string]string{
+		"/uredni-deska":   boardPage(boardItem("/uredni-deska/1", "Oznámení", dateColumn("Vyvěšeno", "1. 10. 2026"))),
+		"/uredni-deska/1": detailsPage(card("<p>Text</p>", [2]string{"Příloha.pdf", "/files/1.pdf"})),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Errorf("expected a request accepting gzip, got Accept-Encoding %q", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		fmt.Fprint(zw, page)
+		zw.Close()
+	}))
+	defer server.Close()
+
+	news, _ := scrape(t, ScraperConfig{Sources: []Source{{Name: "test", URL: server.URL + "/uredni-deska"}}})
+	if len(news) != 1 {
+		t.Fatalf("expected 1 news entry, got %d", len(news))
+	}
+	if news[0].Title != "Oznámení" {
+		t.Errorf("expected the title %q, got %q", "Oznámení", news[0].Title)
+	}
+	if len(news[0].Attachments) != 1 {
+		t.Errorf("expected 1 attachment, got %d", len(news[0].Attachments))
+	}
+}
+
+func TestScrapeDetailsPageTooLarge(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska": boardPage(
+			boardItem("/uredni-deska/1", "Oznámení", dateColumn("Vyvěšeno", "1. 10. 2026")),
+			boardItem("/uredni-deska/2", "Vyhláška", dateColumn("Vyvěšeno", "1. 10. 2026")),
+		),
+		"/uredni-deska/1": detailsPage(card("<p>Text</p>", [2]string{"Příloha.pdf", "/files/1.pdf"})),
+		"/uredni-deska/2": detailsPage(card("<p>"+strings.Repeat("Text ", 1000)+"</p>", [2]string{"Příloha.pdf", "/files/2.pdf"})),
+	})
+
+	news, logs := scrape(t, ScraperConfig{
+		Sources:     []Source{server.source("/uredni-deska")},
+		MaxBodySize: 2000,
+	})
+	if len(news) != 2 {
+		t.Fatalf("expected 2 news entries, got %d", len(news))
+	}
+	for _, newsEntry := range news {
+		want := 1
+		if strings.HasSuffix(newsEntry.EntryURL, "/uredni-deska/2") {
+			want = 0
+		}
+		if len(newsEntry.Attachments) != want {
+			t.Errorf("expected %d attachments of %s, got %d", want, newsEntry.EntryURL, len(newsEntry.Attachments))
+		}
+	}
+	if !strings.Contains(logs.String(), "the details page is too large") {
+		t.Errorf("expected a warning about the too large details page, got logs:\n%s", logs)
+	}
+	if n := server.requestCount("/uredni-deska/2"); n != 1 {
+		t.Errorf("expected the too large details page to be fetched once, got %d requests", n)
+	}
+}
+
+func TestScrapeReferenceNumberInParagraph(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska":   boardPage(boardItem("/uredni-deska/1", "Oznámení", dateColumn("Vyvěšeno", "1. 10. 2026"))),
+		"/uredni-deska/1": detailsPage(card("<p>Č. j.: MUDR 12/2026</p><p>Vyvěšeno 1. 10. 2026</p>")),
+	})
+
+	news, _ := scrape(t, ScraperConfig{Sources: []Source{server.source("/uredni-deska")}})
+	if len(news) != 1 {
+		t.Fatalf("expected 1 news entry, got %d", len(news))
+	}
+	if want := "MUDR 12/2026"; news[0].ReferenceNumber != want {
+		t.Errorf("expected the reference number %q, got %q", want, news[0].ReferenceNumber)
+	}
+}
+
+func TestScrapeLargeBoardInBatches(t *testing.T) {
+	const entries, attachments = 60, 3
+	server := newBoardServer(t, largeBoard(entries, attachments))
+
+	news, _ := scrape(t, ScraperConfig{
+		Sources:       []Source{server.source("/uredni-deska")},
+		DetailThreads: 8,
+		QueueSize:     5,
+	})
+	if len(news) != entries {
+		t.Fatalf("expected %d news entries, got %d", entries, len(news))
+	}
+	for _, newsEntry := range news {
+		var i int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(newsEntry.EntryURL, server.URL), "/uredni-deska/%d", &i); err != nil {
+			t.Fatalf("unexpected URL %s", newsEntry.EntryURL)
+		}
+		if len(newsEntry.Attachments) != attachments {
+			t.Errorf("expected %d attachments of %s, got %d", attachments, newsEntry.EntryURL, len(newsEntry.Attachments))
+			continue
+		}
+		for j, attachment := range newsEntry.Attachments {
+			if want := fmt.Sprintf("/files/%d-%d.pdf", i, j); !strings.HasSuffix(attachment.URL, want) {
+				t.Errorf("expected the attachment %s of %s, got %s", want, newsEntry.EntryURL, attachment.URL)
+			}
+		}
+	}
+	for i := 0; i < entries; i++ {
+		if n := server.requestCount(fmt.Sprintf("/uredni-deska/%d", i)); n != 1 {
+			t.Errorf("expected the details page %d to be fetched once, got %d requests", i, n)
+		}
+	}
+}
+
+func TestScrapeRateLimitedListing(t *testing.T) {
+	pages := map[string]string{
+		"/uredni-deska":   boardPage(boardItem("/uredni-deska/1", "Oznámení", dateColumn("Vyvěšeno", "1. 10. 2026"))),
+		"/uredni-deska/1": detailsPage(card("<p>Text</p>")),
+	}
+	var mu sync.Mutex
+	listingRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/uredni-deska" {
+			mu.Lock()
+			listingRequests++
+			first := listingRequests == 1
+			mu.Unlock()
+			if first {
+				// the delay is capped by the MaxDelay of the retry policy
+				w.Header().Set("Retry-After", "3600")
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+		fmt.Fprint(w, pages[r.URL.Path])
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	news, logs := scrape(t, ScraperConfig{
+		Sources: []Source{{Name: "test", URL: server.URL + "/uredni-deska"}},
+		Retry:   RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	})
+	if len(news) != 1 {
+		t.Fatalf("expected 1 news entry, got %d", len(news))
+	}
+	if listingRequests != 2 {
+		t.Errorf("expected 2 requests of the listing, got %d", listingRequests)
+	}
+	if !strings.Contains(logs.String(), "rate limited by the server") {
+		t.Errorf("expected the rate limit to be logged, got logs:\n%s", logs)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the Retry-After delay to be capped, the scraping took %s", elapsed)
+	}
+}
+
+func TestParseDateColumn(t *testing.T) {
+	tests := []struct {
+		name      string
+		spans     []string
+		wantLabel string
+		wantDate  string
+	}{
+		{"label and date", []string{"Vyvěšeno", "1. 10. 2026"}, "Vyvěšeno", "2026-10-01"},
+		{"date and label", []string{"1. 10. 2026", "Sejmuto"}, "Sejmuto", "2026-10-01"},
+		{"single span with label", []string{"Vyvěšeno: 1. 10. 2026"}, "Vyvěšeno:", "2026-10-01"},
+		{"single span without label", []string{"1. 10. 2026"}, "", "2026-10-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			label, date, err := parseDateColumn(tt.spans)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if label != tt.wantLabel {
+				t.Errorf("expected the label %q, got %q", tt.wantLabel, label)
+			}
+			if !date.Equal(*isoDate(t, tt.wantDate)) {
+				t.Errorf("expected the date %s, got %s", tt.wantDate, date)
+			}
+		})
+	}
+
+	var dateErr *DateParseError
+	if _, _, err := parseDateColumn([]string{"Vyvěšeno"}); !errors.As(err, &dateErr) {
+		t.Errorf("expected a DateParseError for a column without a date, got %v", err)
+	}
+}
+
+// benchmarkNews returns a board of the given number of news entries with a few attachments each.
+func benchmarkNews(entries int) News {
+	publishedOn := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	publishedUntil := time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+	var news News
+	for i := 0; i < entries; i++ {
+		news = append(news, &NewsEntry{
+			Source:          "Drásov",
+			PublishedOn:     &publishedOn,
+			PublishedUntil:  &publishedUntil,
+			Title:           fmt.Sprintf("Oznámení o zveřejnění dokumentu %d", i),
+			Category:        "Oznámení",
+			EntryURL:        fmt.Sprintf("https://www.drasov.cz/uredni-deska/%d", i),
+			ReferenceNumber: fmt.Sprintf("MUDR %d/2026", i),
+			Attachments: []NewsEntryAttachment{
+				{Filename: "Oznámení.pdf", URL: fmt.Sprintf("https://www.drasov.cz/files/%d.pdf", i)},
+				{Filename: "Oznámení.pdf", URL: fmt.Sprintf("https://www.drasov.cz/files/%d.docx", i)},
+				{Filename: "Příloha.pdf", URL: fmt.Sprintf("https://www.drasov.cz/files/%d-1.pdf", i)},
+			},
+		})
+	}
+	return news
+}
+
+func TestNewsString(t *testing.T) {
+	publishedOn := isoDate(t, "2026-10-01")
+	news := News{
+		{
+			Source:                 "Drásov",
+			PublishedOn:            publishedOn,
+			PublishedUntil:         isoDate(t, "2026-10-31"),
+			PublishedUntilInferred: true,
+			Title:                  "Oznámení",
+			Category:               "Oznámení",
+			EntryURL:               "https://www.drasov.cz/uredni-deska/1",
+			ElectronicOnly:         true,
+			ReferenceNumber:        "MUDR 12/2026",
+			Authority:              "Stavební úřad",
+			Attachments: []NewsEntryAttachment{
+				{Filename: "Oznámení.pdf", URL: "https://www.drasov.cz/files/1.pdf"},
+				{Filename: "Příloha.pdf", URL: "https://www.drasov.cz/files/2.pdf"},
+				{Filename: "Oznámení.pdf", URL: "https://www.drasov.cz/files/1.docx"},
+			},
+		},
+		{
+			Source:      "Drásov",
+			PublishedOn: publishedOn,
+			Title:       "Vyhláška",
+			EntryURL:    "https://www.drasov.cz/uredni-deska/2",
+		},
+	}
+
+	want := `Title: Oznámení
+Source: Drásov
+Category: Oznámení
+Published on: ` + formatDate(publishedOn) + `
+Published until: ` + formatDate(news[0].PublishedUntil) + ` (inferred)
+Electronic only: yes
+Reference number: MUDR 12/2026
+Authority: Stavební úřad
+URL: https://www.drasov.cz/uredni-deska/1
+Attachments:
+  Oznámení.pdf: https://www.drasov.cz/files/1.pdf
+  Příloha.pdf: https://www.drasov.cz/files/2.pdf
+  Oznámení.pdf: https://www.drasov.cz/files/1.docx
+
+Title: Vyhláška
+Source: Drásov
+Published on: ` + formatDate(publishedOn) + `
+Published until: unknown
+URL: https://www.drasov.cz/uredni-deska/2
+`
+	if got := news.String(); got != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+
+	wantGrouped := strings.Replace(want, `  Oznámení.pdf: https://www.drasov.cz/files/1.pdf
+  Příloha.pdf: https://www.drasov.cz/files/2.pdf
+  Oznámení.pdf: https://www.drasov.cz/files/1.docx
+`, `  Oznámení.pdf: https://www.drasov.cz/files/1.pdf, https://www.drasov.cz/files/1.docx
+  Příloha.pdf: https://www.drasov.cz/files/2.pdf
+`, 1)
+	if got := news.format(true); got != wantGrouped {
+		t.Errorf("unexpected grouped output:\n%s\nwant:\n%s", got, wantGrouped)
+	}
+
+	// the board is the entries separated by an empty line
+	large := benchmarkNews(500)
+	var entries []string
+	for _, newsEntry := range large {
+		entries = append(entries, newsEntry.String())
+	}
+	if got, want := large.String(), strings.Join(entries, "\n"); got != want {
+		t.Errorf("unexpected output of a large board")
+	}
+}
+
+func BenchmarkNewsString(b *testing.B) {
+	news := benchmarkNews(500)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = news.String()
+	}
+}
+
+func TestCleanAttachmentFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Rozpočet 2024.pdf (1,2 MB)", "Rozpočet 2024.pdf"},
+		{"dokument.pdf (250 kB)", "dokument.pdf"},
+		{"  Příloha.docx  ", "Příloha.docx"},
+		{"Příloha (2).pdf", "Příloha (2).pdf"},
+	}
+	for _, tt := range tests {
+		if got := CleanAttachmentFilename(tt.name); got != tt.want {
+			t.Errorf("CleanAttachmentFilename(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScrapeCleanFilenames(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska":   boardPage(boardItem("/uredni-deska/1", "Rozpočet", dateColumn("Vyvěšeno", "1. 10. 2026"))),
+		"/uredni-deska/1": detailsPage(card("<p>Text</p>", [2]string{"Rozpočet 2024.pdf (1,2 MB)", "/files/1.pdf"})),
+	})
+
+	for _, clean := range []bool{false, true} {
+		news, _ := scrape(t, ScraperConfig{
+			Sources:        []Source{server.source("/uredni-deska")},
+			CleanFilenames: clean,
+		})
+		want := "Rozpočet 2024.pdf (1,2 MB)"
+		if clean {
+			want = "Rozpočet 2024.pdf"
+		}
+		if len(news) != 1 || len(news[0].Attachments) != 1 {
+			t.Fatalf("expected 1 news entry with 1 attachment, got %v", news)
+		}
+		if got := news[0].Attachments[0].Filename; got != want {
+			t.Errorf("expected the filename %q with CleanFilenames %t, got %q", want, clean, got)
+		}
+	}
+}
+
+func TestRepeatRunsRightAway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan time.Time)
+	runs := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		repeat(ctx, ticks, func() error {
+			runs <- struct{}{}
+			return nil
+		})
+	}()
+
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("expected the first run right away, before the first tick")
+	}
+	ticks <- time.Now()
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("expected a run after the tick")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected repeat to return, once the context is done")
+	}
+}
+
+func TestStringDateToTime(t *testing.T) {
+	tests := []struct {
+		date string
+		want time.Time
+	}{
+		{"1. 12. 2021", time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{"1.12.2021", time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{"15. 3. 2024 14:30", time.Date(2024, 3, 15, 14, 30, 0, 0, pragueLocation)},
+		{"15. 3. 2024  9:05", time.Date(2024, 3, 15, 9, 5, 0, 0, pragueLocation)},
+		{"29. 2. 2024", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := StringDateToTime(tt.date)
+		if err != nil {
+			t.Errorf("StringDateToTime(%q) failed: %s", tt.date, err)
+			continue
+		}
+		if !got.Equal(tt.want) || got.Location() != tt.want.Location() {
+			t.Errorf("StringDateToTime(%q) = %s, want %s", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestStringDateToTimeInvalid(t *testing.T) {
+	tests := []struct {
+		date  string
+		field string
+	}{
+		{"1. 12.", ""},
+		{"1. 12. 2021 14:30 extra", ""},
+		{"x. 12. 2021", "day"},
+		{"1. x. 2021", "month"},
+		{"1. 12. 2021 25:00", "time"},
+		{"1. 12. 202", "year"},
+		{"1. 12. 20210", "year"},
+		{"31. 2. 2021", "day"},
+		{"29. 2. 2021", "day"},
+		{"0. 12. 2021", "day"},
+		{"0. 13. 2021", "month"},
+		{"1. 0. 2021", "month"},
+	}
+	for _, tt := range tests {
+		_, err := StringDateToTime(tt.date)
+		var dateErr *DateParseError
+		if !errors.As(err, &dateErr) {
+			t.Errorf("StringDateToTime(%q) = %v, want a DateParseError", tt.date, err)
+			continue
+		}
+		if dateErr.Field != tt.field {
+			t.Errorf("StringDateToTime(%q) failed on the field %q, want %q", tt.date, dateErr.Field, tt.field)
+		}
+	}
+}
+
+// warningsOf returns the warnings recorded by scraping the sources of the config, as with -strict.
+func warningsOf(t *testing.T, config ScraperConfig) []string {
+	t.Helper()
+	logger, logs := testLogger()
+	warnings := &recordedWarnings{}
+	config.Logger = slog.New(warningRecorder{Handler: logger.Handler(), warnings: warnings})
+	if _, err := NewScraper(config).Scrape(context.Background()); err != nil {
+		t.Fatalf("scraping failed: %s\nlogs:\n%s", err, logs)
+	}
+	return warnings.take()
+}
+
+func TestScrapeStrictWarnings(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska": boardPage(
+			boardItem("/uredni-deska/1", "Oznámení", dateColumn("Vyvěšeno", "1. 10. 2026")),
+			boardItem("/uredni-deska/2", "Bez data"),
+			`<div class="c-office-board__content-item"><div class="c-office-board__col-name-content">Bez odkazu</div>`+
+				`<div class="c-office-board__col-date">`+dateColumn("Vyvěšeno", "1. 10. 2026")+`</div></div>`,
+		),
+		"/uredni-deska/1": detailsPage(card("<p>Text</p>")),
+		"/uredni-deska/2": detailsPage(card("<p>Text</p>")),
+	})
+
+	warnings := warningsOf(t, ScraperConfig{Sources: []Source{server.source("/uredni-deska")}})
+	for _, want := range []string{"news entry has no date", "news entry has no title link"} {
+		found := false
+		for _, warning := range warnings {
+			found = found || strings.HasPrefix(warning, want)
+		}
+		if !found {
+			t.Errorf("expected the warning %q, got %q", want, warnings)
+		}
+	}
+}
+
+func TestScrapeRecoveredListingFetch(t *testing.T) {
+	pages := map[string]string{
+		"/uredni-deska":   boardPage(boardItem("/uredni-deska/1", "Oznámení", dateColumn("Vyvěšeno", "1. 10. 2026"))),
+		"/uredni-deska/1": detailsPage(card("<p>Text</p>")),
+	}
+	var mu sync.Mutex
+	listingRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/uredni-deska" {
+			mu.Lock()
+			listingRequests++
+			first := listingRequests == 1
+			mu.Unlock()
+			if first {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		fmt.Fprint(w, pages[r.URL.Path])
+	}))
+	defer server.Close()
+
+	// the fetch fails once, but succeeds on the next startup attempt, so no warning is logged
+	warnings := warningsOf(t, ScraperConfig{
+		Sources:      []Source{{Name: "test", URL: server.URL + "/uredni-deska"}},
+		StartupRetry: RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+	})
+	if listingRequests != 2 {
+		t.Errorf("expected 2 requests of the listing, got %d", listingRequests)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("expected no warnings, got %q", warnings)
+	}
+}
+
+func TestScrapeAuthorityInParagraph(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska":   boardPage(boardItem("/uredni-deska/1", "Oznámení", dateColumn("Vyvěšeno", "1. 10. 2026"))),
+		"/uredni-deska/1": detailsPage(card("<p>Původce: Stavební úřad</p><p>Č. j.: MUDR 12/2026</p>")),
+	})
+
+	news, _ := scrape(t, ScraperConfig{Sources: []Source{server.source("/uredni-deska")}})
+	if len(news) != 1 {
+		t.Fatalf("expected 1 news entry, got %d", len(news))
+	}
+	if want := "Stavební úřad"; news[0].Authority != want {
+		t.Errorf("expected the authority %q, got %q", want, news[0].Authority)
+	}
+	if want := "MUDR 12/2026"; news[0].ReferenceNumber != want {
+		t.Errorf("expected the reference number %q, got %q", want, news[0].ReferenceNumber)
+	}
+}
+
+func TestScrapeCrossLinkedBoards(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/a": boardPage(
+			boardItem("/d/1", "Oznámení 1", dateColumn("Vyvěšeno", "1. 10. 2026")),
+			boardItem("/d/2", "Oznámení 2", dateColumn("Vyvěšeno", "1. 10. 2026")),
+		),
+		// links the details page listed by the first board and the first board itself
+		"/b": boardPage(
+			boardItem("/d/2", "Oznámení 2", dateColumn("Vyvěšeno", "1. 10. 2026")),
+			boardItem("/d/3", "Oznámení 3", dateColumn("Vyvěšeno", "1. 10. 2026")),
+			boardItem("/a", "Úřední deska A", dateColumn("Vyvěšeno", "1. 10. 2026")),
+		),
+		"/d/1": detailsPage(card(`<p><a href="/d/2">Související</a></p>`, [2]string{"1.pdf", "/files/1.pdf"})),
+		"/d/2": detailsPage(card(`<p><a href="/d/1">Související</a></p>`, [2]string{"2.pdf", "/files/2.pdf"})),
+		"/d/3": detailsPage(card(`<p><a href="/b">Úřední deska B</a></p>`, [2]string{"3.pdf", "/files/3.pdf"})),
+	})
+
+	news, _ := scrape(t, ScraperConfig{
+		Sources:       []Source{{Name: "A", URL: server.URL + "/a"}, {Name: "B", URL: server.URL + "/b"}},
+		DetailThreads: 4,
+	})
+	if len(news) != 5 {
+		t.Errorf("expected 5 news entries, got %d: %v", len(news), entryURLs(news))
+	}
+	for _, path := range []string{"/a", "/b", "/d/1", "/d/2", "/d/3"} {
+		if n := server.requestCount(path); n != 1 {
+			t.Errorf("expected %s to be fetched once, got %d requests", path, n)
+		}
+	}
+	// the details of a page visited by another source are still copied
+	for _, newsEntry := range news {
+		if strings.HasSuffix(newsEntry.EntryURL, "/d/2") && len(newsEntry.Attachments) != 1 {
+			t.Errorf("expected 1 attachment of %s of the source %s, got %d", newsEntry.EntryURL, newsEntry.Source, len(newsEntry.Attachments))
+		}
+	}
+}
+
+func TestAtomicFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "news.txt")
+	if err := os.WriteFile(name, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	aborted, err := createAtomicFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(aborted, "partial")
+	aborted.Abort()
+	if content, _ := os.ReadFile(name); string(content) != "old" {
+		t.Errorf("expected the file to be unchanged after Abort, got %q", content)
+	}
+
+	committed, err := createAtomicFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(committed, "new")
+	if err := committed.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(name); string(content) != "new" {
+		t.Errorf("expected the committed content, got %q", content)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("expected no temporary files left, got %d files", len(files))
+	}
+}
+
+func TestScrapeBoardListedByAnotherBoard(t *testing.T) {
+	server := newBoardServer(t, map[string]string{
+		"/a": boardPage(
+			boardItem("/d/1", "Oznámení 1", dateColumn("Vyvěšeno", "1. 10. 2026")),
+			boardItem("/b", "Úřední deska B", dateColumn("Vyvěšeno", "1. 10. 2026")),
+		),
+		"/b": boardPage(
+			boardItem("/d/2", "Oznámení 2", dateColumn("Vyvěšeno", "1. 10. 2026")),
+			boardItem("/d/3", "Oznámení 3", dateColumn("Vyvěšeno", "1. 10. 2026")),
+		),
+		"/d/1": detailsPage(card("<p>Text</p>")),
+		"/d/2": detailsPage(card("<p>Text</p>")),
+		"/d/3": detailsPage(card("<p>Text</p>")),
+	})
+
+	// the board B is fetched as a details page of the board A first, but it is still scraped
+	news, logs := scrape(t, ScraperConfig{
+		Sources: []Source{{Name: "A", URL: server.URL + "/a"}, {Name: "B", URL: server.URL + "/b"}},
+	})
+	var got []string
+	for _, newsEntry := range news {
+		got = append(got, newsEntry.Source+" "+strings.TrimPrefix(newsEntry.EntryURL, server.URL))
+	}
+	want := []string{"A /d/1", "A /b", "B /d/2", "B /d/3"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("expected the news entries %q, got %q", want, got)
+	}
+	if strings.Contains(logs.String(), "skipping the board") {
+		t.Errorf("expected the board B not to be skipped, got logs:\n%s", logs)
+	}
+}
+
+func TestScrapeKeepsListingOrder(t *testing.T) {
+	const entries = 10
+	pages := map[string]string{}
+	var want []string
+	for _, board := range []string{"a", "b"} {
+		var items []string
+		for i := 0; i < entries; i++ {
+			href := fmt.Sprintf("/%s/%d", board, i)
+			items = append(items, boardItem(href, fmt.Sprintf("Oznámení %s%d", board, i), dateColumn("Vyvěšeno", "1. 10. 2026")))
+			pages[href] = detailsPage(card("<p>Text</p>"))
+			want = append(want, href)
+		}
+		pages["/"+board] = boardPage(items...)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the later entries are fetched faster, so that they are scraped before the earlier ones
+		var board string
+		var i int
+		if _, err := fmt.Sscanf(r.URL.Path, "/%1s/%d", &board, &i); err == nil {
+			time.Sleep(time.Duration(entries-i) * 5 * time.Millisecond)
+		}
+		fmt.Fprint(w, pages[r.URL.Path])
+	}))
+	defer server.Close()
+
+	news, _ := scrape(t, ScraperConfig{
+		Sources:       []Source{{Name: "A", URL: server.URL + "/a"}, {Name: "B", URL: server.URL + "/b"}},
+		DetailThreads: 4,
+	})
+	var got []string
+	for _, newsEntry := range news {
+		got = append(got, strings.TrimPrefix(newsEntry.EntryURL, server.URL))
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("expected the news entries in the listing order %v, got %v", want, got)
+	}
+}
+
+func TestScrapeElectronicOnly(t *testing.T) {
+	item := func(href, title string) string {
+		return boardItem(href, title, dateColumn("Vyvěšeno", "1. 10. 2026"))
+	}
+	server := newBoardServer(t, map[string]string{
+		"/uredni-deska": boardPage(
+			item("/uredni-deska/1", "Vyvěšeno fyzicky"),
+			// the selectors are guessed, see electronicOnlySelector
+			strings.Replace(item("/uredni-deska/2", "Modifikátor"), `"c-office-board__content-item"`,
+				`"c-office-board__content-item c-office-board__content-item--electronic"`, 1),
+			strings.Replace(item("/uredni-deska/3", "Ikona"), `</a>`,
+				`</a><span class="c-office-board__icon-electronic"></span>`, 1),
+		),
+		"/uredni-deska/1": detailsPage(card("<p>Text</p>")),
+		"/uredni-deska/2": detailsPage(card("<p>Text</p>")),
+		"/uredni-deska/3": detailsPage(card("<p>Text</p>")),
+	})
+
+	news, _ := scrape(t, ScraperConfig{Sources: []Source{server.source("/uredni-deska")}})
+	if len(news) != 3 {
+		t.Fatalf("expected 3 news entries, got %d", len(news))
+	}
+	for _, newsEntry := range news {
+		want := !strings.HasSuffix(newsEntry.EntryURL, "/uredni-deska/1")
+		if newsEntry.ElectronicOnly != want {
+			t.Errorf("expected ElectronicOnly %t of %q, got %t", want, newsEntry.Title, newsEntry.ElectronicOnly)
+		}
+	}
+	if physical := news.PhysicallyPosted(); len(physical) != 1 || physical[0].Title != "Vyvěšeno fyzicky" {
+		t.Errorf("expected only the physically posted entry, got %v", entryURLs(physical))
+	}
+}
@@ -0,0 +1,760 @@
+/*
+ * www.drasov.cz/uredni-deska news scraper
+ *
+ * Copyright (C) 2023  Tomáš Hozza
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Renderer renders news entries in a specific output format.
+type Renderer interface {
+	Render(w io.Writer, n News) error
+}
+
+// RendererFunc is an adapter to allow the use of ordinary functions as renderers.
+type RendererFunc func(w io.Writer, n News) error
+
+// Render calls f(w, n).
+func (f RendererFunc) Render(w io.Writer, n News) error {
+	return f(w, n)
+}
+
+// renderers maps the output format names to their renderers.
+var renderers = map[string]Renderer{
+	"text":       TextRenderer{},
+	"csv":        CSVRenderer{},
+	"env":        EnvRenderer{},
+	"titles":     TitlesRenderer{},
+	"canonical":  CanonicalRenderer{},
+	"summary":    SummaryRenderer{},
+	"xml":        XMLRenderer{},
+	"aria2":      Aria2Renderer{},
+	"prometheus": PrometheusRenderer{},
+	"debug":      DebugRenderer{},
+	"frequency":  FrequencyRenderer{},
+}
+
+// RegisterRenderer registers the renderer for the given output format name,
+// replacing any renderer previously registered for the name.
+func RegisterRenderer(name string, r Renderer) {
+	renderers[name] = r
+}
+
+// LookupRenderer returns the renderer registered for the given output format name.
+func LookupRenderer(name string) (Renderer, bool) {
+	r, ok := renderers[name]
+	return r, ok
+}
+
+// RendererNames returns the sorted names of all registered output formats.
+func RendererNames() []string {
+	names := maps.Keys(renderers)
+	sort.Strings(names)
+	return names
+}
+
+// TextRenderer renders news entries as human-readable text.
+type TextRenderer struct {
+	// Since is the date since which the rendered news entries were published. If set, it is mentioned
+	// in the header of the output.
+	Since time.Time
+	// GroupAttachments enables listing the attachments with the same filename on a single line.
+	GroupAttachments bool
+	// Fields are the names of the fields to write for each news entry, in the given order, see EntryFields.
+	// If empty, all fields are written.
+	Fields []string
+	// Header and Footer are written on their own lines before and after the news entries, if set.
+	// The placeholders "{count}" and "{date}" are replaced by the number of the news entries and
+	// the current date, any other text is written as it is.
+	Header, Footer string
+}
+
+func (r TextRenderer) Render(w io.Writer, n News) error {
+	if err := validateFields(r.Fields); err != nil {
+		return err
+	}
+	if r.Header != "" {
+		if _, err := fmt.Fprintln(w, r.expandPlaceholders(r.Header, n)); err != nil {
+			return err
+		}
+	}
+	if err := r.render(w, n); err != nil {
+		return err
+	}
+	if r.Footer != "" {
+		if _, err := fmt.Fprintln(w, r.expandPlaceholders(r.Footer, n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandPlaceholders replaces the placeholders in the header or footer text, see TextRenderer.Header.
+func (r TextRenderer) expandPlaceholders(text string, n News) string {
+	return strings.NewReplacer(
+		"{count}", strconv.Itoa(len(n)),
+		"{date}", NowDate().Format(dateFormat),
+	).Replace(text)
+}
+
+// render writes the news entries without the header and footer.
+func (r TextRenderer) render(w io.Writer, n News) error {
+	var err error
+	switch {
+	case r.Since.IsZero():
+		_, err = fmt.Fprintf(w, "Found %d news entries:\n", len(n))
+	case len(n) == 0:
+		_, err = fmt.Fprintf(w, "Found no news entries published since %s\n", r.Since.Format(dateFormat))
+		return err
+	default:
+		_, err = fmt.Fprintf(w, "Found %d news entries published since %s:\n", len(n), r.Since.Format(dateFormat))
+	}
+	if err != nil {
+		return err
+	}
+	if len(r.Fields) > 0 {
+		_, err = fmt.Fprintln(w, n.formatFields(r.Fields))
+	} else {
+		_, err = fmt.Fprintln(w, n.format(r.GroupAttachments))
+	}
+	return err
+}
+
+// CSVRenderer renders news entries as CSV.
+type CSVRenderer struct {
+	Options CSVOptions
+}
+
+func (r CSVRenderer) Render(w io.Writer, n News) error {
+	return n.WriteCSV(w, r.Options)
+}
+
+// EnvRenderer renders news entries as shell variable assignments.
+type EnvRenderer struct{}
+
+func (r EnvRenderer) Render(w io.Writer, n News) error {
+	return n.WriteEnv(w)
+}
+
+// XMLRenderer renders news entries as XML.
+type XMLRenderer struct{}
+
+func (r XMLRenderer) Render(w io.Writer, n News) error {
+	return n.WriteXML(w)
+}
+
+// Aria2Renderer renders the attachments of news entries as an aria2c input file, which downloads
+// the attachments of each news entry into its own directory, e.g. "scraper -format=aria2 | aria2c -i -".
+type Aria2Renderer struct {
+	Options Aria2Options
+}
+
+func (r Aria2Renderer) Render(w io.Writer, n News) error {
+	return n.WriteAria2(w, r.Options)
+}
+
+// DebugRenderer renders news entries as human-readable text, each followed by its HTML on the board listing,
+// if kept, see ScraperConfig.KeepRaw.
+type DebugRenderer struct{}
+
+func (r DebugRenderer) Render(w io.Writer, n News) error {
+	for _, newsEntry := range n {
+		_, err := fmt.Fprintf(w, "%s\nRaw HTML:\n%s\n\n", newsEntry, newsEntry.RawHTML)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TitlesRenderer renders only the titles of news entries, one per line.
+type TitlesRenderer struct {
+	// WithDate enables prefixing each title with the publication date of the entry.
+	WithDate bool
+}
+
+func (r TitlesRenderer) Render(w io.Writer, n News) error {
+	for _, newsEntry := range n {
+		var err error
+		if r.WithDate {
+			_, err = fmt.Fprintf(w, "%s %s\n", formatDate(newsEntry.PublishedOn), newsEntry.Title)
+		} else {
+			_, err = fmt.Fprintln(w, newsEntry.Title)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SummaryRenderer renders only the aggregate statistics of news entries, see News.Summary.
+type SummaryRenderer struct {
+	// At is the time at which the statistics are computed. If zero, the current date is used.
+	At time.Time
+}
+
+func (r SummaryRenderer) Render(w io.Writer, n News) error {
+	at := r.At
+	if at.IsZero() {
+		at = NowDate()
+	}
+	stats := n.Summary(at)
+	_, err := fmt.Fprintf(w, "Entries: %d\nWith attachments: %d\nAttachments: %d\nOldest: %s\nNewest: %s\nExpiring this week: %d\n",
+		stats.Entries, stats.WithAttachments, stats.Attachments, formatDate(stats.Oldest), formatDate(stats.Newest), stats.ExpiringThisWeek)
+	return err
+}
+
+// PrometheusRenderer renders the aggregate statistics of news entries as gauges in the Prometheus text
+// exposition format, e.g. for the textfile collector of the node exporter.
+type PrometheusRenderer struct {
+	// Now is the time to which the age of the oldest news entry is computed. If zero, the current time is used.
+	Now time.Time
+}
+
+func (r PrometheusRenderer) Render(w io.Writer, n News) error {
+	now := r.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	stats := n.Summary(now)
+
+	var sb strings.Builder
+	writeGauge := func(name, help string, value float64) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	writeGauge("drasov_board_entries_total", "Number of news entries on the board.", float64(stats.Entries))
+	writeGauge("drasov_board_attachments_total", "Number of attachments of all news entries on the board.", float64(stats.Attachments))
+	// without any posting date, there is no meaningful age to report
+	if stats.Oldest != nil {
+		writeGauge("drasov_board_oldest_entry_age_seconds", "Time since the posting date of the oldest news entry on the board.",
+			now.Sub(*stats.Oldest).Truncate(time.Second).Seconds())
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// FrequencyRenderer renders the number of news entries posted in each month as a histogram,
+// see News.PostingFrequency.
+type FrequencyRenderer struct{}
+
+// frequencyBarWidth is the width of the longest bar of the frequency histogram.
+const frequencyBarWidth = 50
+
+func (r FrequencyRenderer) Render(w io.Writer, n News) error {
+	frequency := n.PostingFrequency()
+	months := maps.Keys(frequency)
+	sort.Strings(months)
+
+	maxCount := 0
+	for _, count := range frequency {
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+	for _, month := range months {
+		count := frequency[month]
+		// every month with an entry gets at least a single mark
+		bar := (count*frequencyBarWidth + maxCount - 1) / maxCount
+		if _, err := fmt.Fprintf(w, "%s %s %d\n", month, strings.Repeat("#", bar), count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CanonicalRenderer renders news entries in a stable, diff-friendly text format. The entries and their
+// attachments are sorted by their URL, dates are formatted as ISO dates and each field is on its own line.
+// Given the same news entries, the output is always byte-identical.
+type CanonicalRenderer struct{}
+
+func (r CanonicalRenderer) Render(w io.Writer, n News) error {
+	news := make(News, len(n))
+	copy(news, n)
+	sort.SliceStable(news, func(i, j int) bool {
+		a, b := news[i], news[j]
+		if a.EntryURL != b.EntryURL {
+			return a.EntryURL < b.EntryURL
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Title < b.Title
+	})
+
+	// only escapes newlines, so that each field stays on a single line
+	escape := strings.NewReplacer("\\", `\\`, "\n", `\n`, "\r", `\r`).Replace
+
+	var sb strings.Builder
+	writeField := func(name, value string) {
+		sb.WriteString(name)
+		sb.WriteString(":")
+		if value != "" {
+			sb.WriteString(" ")
+			sb.WriteString(escape(value))
+		}
+		sb.WriteString("\n")
+	}
+
+	for idx, newsEntry := range news {
+		if idx > 0 {
+			sb.WriteString("\n")
+		}
+		writeField("url", newsEntry.EntryURL)
+		writeField("source", newsEntry.Source)
+		writeField("title", newsEntry.Title)
+		writeField("category", newsEntry.Category)
+		writeField("published_on", formatISODate(newsEntry.PublishedOn))
+		writeField("published_until", formatISODate(newsEntry.PublishedUntil))
+		// written only when set, to keep the output of the other entries unchanged
+		if newsEntry.ElectronicOnly {
+			writeField("electronic_only", "true")
+		}
+		if newsEntry.ReferenceNumber != "" {
+			writeField("reference_number", newsEntry.ReferenceNumber)
+		}
+		if newsEntry.Authority != "" {
+			writeField("authority", newsEntry.Authority)
+		}
+
+		attachments := make([]NewsEntryAttachment, len(newsEntry.Attachments))
+		copy(attachments, newsEntry.Attachments)
+		sort.SliceStable(attachments, func(i, j int) bool {
+			if attachments[i].URL != attachments[j].URL {
+				return attachments[i].URL < attachments[j].URL
+			}
+			return attachments[i].Filename < attachments[j].Filename
+		})
+		for _, attachment := range attachments {
+			writeField("attachment_url", attachment.URL)
+			writeField("attachment_filename", attachment.Filename)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// ContentHash returns the hex-encoded SHA-256 hash of the news entries rendered by CanonicalRenderer.
+// The hash doesn't depend on the order of the entries and their attachments, so it changes only
+// if the entries themselves change.
+func (n News) ContentHash() string {
+	h := sha256.New()
+	// writing to a hash never fails
+	_ = CanonicalRenderer{}.Render(h, n)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// isoDateFormat is the date format used in machine-readable outputs.
+const isoDateFormat = "2006-01-02"
+
+// utf8BOM is the UTF-8 byte order mark, which helps e.g. Excel to detect the encoding of a CSV file.
+const utf8BOM = "\uFEFF"
+
+// formatISODate formats the given date using isoDateFormat, or returns an empty string if the date is not set.
+func formatISODate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(isoDateFormat)
+}
+
+// CSVOptions holds the options for writing news entries as CSV.
+type CSVOptions struct {
+	// Delimiter is the field delimiter. If zero, a comma is used.
+	Delimiter rune
+	// BOM enables writing the UTF-8 byte order mark at the start of the output.
+	BOM bool
+	// Fields are the names of the columns to write, in the given order, see EntryFields.
+	// If empty, all columns are written.
+	Fields []string
+}
+
+// ValidCSVDelimiter reports whether the rune can be used as the CSVOptions.Delimiter, i.e. it is a valid
+// character, which is neither a quote nor a line break, as required by csv.Writer.
+func ValidCSVDelimiter(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+// EntryFields are the names of the news entry fields, which can be selected for the output,
+// in their default order.
+var EntryFields = []string{
+	"source",
+	"title",
+	"category",
+	"published_on",
+	"published_until",
+	"url",
+	"attachments",
+	"electronic_only",
+	"reference_number",
+	"authority",
+}
+
+// entryFieldLabels are the labels of the news entry fields in the text output.
+var entryFieldLabels = map[string]string{
+	"source":           "Source",
+	"title":            "Title",
+	"category":         "Category",
+	"published_on":     "Published on",
+	"published_until":  "Published until",
+	"url":              "URL",
+	"attachments":      "Attachments",
+	"electronic_only":  "Electronic only",
+	"reference_number": "Reference number",
+	"authority":        "Authority",
+}
+
+// validateFields returns an error, if any of the given field names is not one of EntryFields.
+func validateFields(fields []string) error {
+	for _, field := range fields {
+		if _, ok := entryFieldLabels[field]; !ok {
+			return fmt.Errorf("unknown news entry field %q, valid fields are: %s", field, strings.Join(EntryFields, ", "))
+		}
+	}
+	return nil
+}
+
+// entryFieldValue returns the value of the news entry field with the given name, see EntryFields.
+// Attachments are returned one attachment per line. The field must be validated, see validateFields.
+func entryFieldValue(newsEntry *NewsEntry, field string) string {
+	switch field {
+	case "source":
+		return newsEntry.Source
+	case "title":
+		return newsEntry.Title
+	case "category":
+		return newsEntry.Category
+	case "published_on":
+		return formatISODate(newsEntry.PublishedOn)
+	case "published_until":
+		return formatISODate(newsEntry.PublishedUntil)
+	case "url":
+		return newsEntry.EntryURL
+	case "attachments":
+		var attachments []string
+		for _, attachment := range newsEntry.Attachments {
+			attachments = append(attachments, attachment.String())
+		}
+		return strings.Join(attachments, "\n")
+	case "electronic_only":
+		return strconv.FormatBool(newsEntry.ElectronicOnly)
+	case "reference_number":
+		return newsEntry.ReferenceNumber
+	case "authority":
+		return newsEntry.Authority
+	default:
+		panic(fmt.Sprintf("unknown news entry field %q", field))
+	}
+}
+
+// formatFields returns a string representation of the news entries with only the given fields,
+// see EntryFields. Like in NewsEntry.String, dates are written in the dateFormat, empty fields are
+// omitted and attachments are written indented on the following lines.
+func (n News) formatFields(fields []string) string {
+	var sb strings.Builder
+	for idx, newsEntry := range n {
+		if idx > 0 {
+			sb.WriteString("\n")
+		}
+		for _, field := range fields {
+			if field == "attachments" {
+				if len(newsEntry.Attachments) == 0 {
+					continue
+				}
+				sb.WriteString("Attachments:\n")
+				for _, attachment := range newsEntry.Attachments {
+					sb.WriteString("  " + attachment.String() + "\n")
+				}
+				continue
+			}
+			var value string
+			switch field {
+			case "published_on":
+				value = formatDate(newsEntry.PublishedOn)
+			case "published_until":
+				value = formatDate(newsEntry.PublishedUntil)
+			default:
+				value = entryFieldValue(newsEntry, field)
+			}
+			if value != "" {
+				sb.WriteString(entryFieldLabels[field] + ": " + value + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// WriteCSV writes the news entries as CSV, including a header row, to the given writer.
+// Attachments of an entry are written into a single field, one attachment per line.
+func (n News) WriteCSV(w io.Writer, opts CSVOptions) error {
+	if err := validateFields(opts.Fields); err != nil {
+		return err
+	}
+	if opts.BOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		cw.Comma = opts.Delimiter
+	}
+
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = EntryFields
+	}
+
+	if err := cw.Write(fields); err != nil {
+		return err
+	}
+
+	for _, newsEntry := range n {
+		record := make([]string, len(fields))
+		for idx, field := range fields {
+			record[idx] = entryFieldValue(newsEntry, field)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// envVarPrefix is the prefix of the names of shell variables written by WriteEnv.
+const envVarPrefix = "DRASOV_"
+
+// shellQuote quotes the given string for safe use in a POSIX shell, by enclosing it in single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// WriteEnv writes the news entries as shell variable assignments, which can be sourced by a shell.
+// The variables of the first entry are written without a suffix, e.g. DRASOV_TITLE, and the variables
+// of all entries are additionally written with the entry index as a suffix, e.g. DRASOV_TITLE_0.
+// The number of entries is written as DRASOV_COUNT.
+func (n News) WriteEnv(w io.Writer) error {
+	writeEntry := func(newsEntry *NewsEntry, suffix string) error {
+		vars := [][2]string{
+			{"TITLE", newsEntry.Title},
+			{"CATEGORY", newsEntry.Category},
+			{"URL", newsEntry.EntryURL},
+			{"PUBLISHED", formatISODate(newsEntry.PublishedOn)},
+		}
+		for _, v := range vars {
+			_, err := fmt.Fprintf(w, "%s%s%s=%s\n", envVarPrefix, v[0], suffix, shellQuote(v[1]))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "%sCOUNT=%d\n", envVarPrefix, len(n)); err != nil {
+		return err
+	}
+	if len(n) == 0 {
+		return nil
+	}
+
+	if err := writeEntry(n[0], ""); err != nil {
+		return err
+	}
+	for idx, newsEntry := range n {
+		if err := writeEntry(newsEntry, fmt.Sprintf("_%d", idx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xmlNews is the XML representation of news entries.
+type xmlNews struct {
+	XMLName xml.Name   `xml:"news"`
+	Entries []xmlEntry `xml:"entry"`
+}
+
+// xmlEntry is the XML representation of a single news entry.
+type xmlEntry struct {
+	Source          string          `xml:"source"`
+	Title           string          `xml:"title"`
+	Category        string          `xml:"category,omitempty"`
+	PublishedOn     string          `xml:"published_on,omitempty"`
+	PublishedUntil  string          `xml:"published_until,omitempty"`
+	URL             string          `xml:"url"`
+	ElectronicOnly  bool            `xml:"electronic_only,omitempty"`
+	ReferenceNumber string          `xml:"reference_number,omitempty"`
+	Authority       string          `xml:"authority,omitempty"`
+	Attachments     []xmlAttachment `xml:"attachments>attachment"`
+}
+
+// xmlAttachment is the XML representation of a news entry attachment.
+type xmlAttachment struct {
+	Filename string `xml:"filename"`
+	URL      string `xml:"url"`
+}
+
+// WriteXML writes the news entries as an XML document with a <news> root element and an <entry>
+// element for each news entry. Dates are written as ISO dates and omitted, if unknown.
+func (n News) WriteXML(w io.Writer) error {
+	doc := xmlNews{Entries: []xmlEntry{}}
+	for _, newsEntry := range n {
+		entry := xmlEntry{
+			Source:          newsEntry.Source,
+			Title:           newsEntry.Title,
+			Category:        newsEntry.Category,
+			PublishedOn:     formatISODate(newsEntry.PublishedOn),
+			PublishedUntil:  formatISODate(newsEntry.PublishedUntil),
+			URL:             newsEntry.EntryURL,
+			ElectronicOnly:  newsEntry.ElectronicOnly,
+			ReferenceNumber: newsEntry.ReferenceNumber,
+			Authority:       newsEntry.Authority,
+		}
+		for _, attachment := range newsEntry.Attachments {
+			entry.Attachments = append(entry.Attachments, xmlAttachment{Filename: attachment.Filename, URL: attachment.URL})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// sanitizeFilename returns the name with the characters, which are not allowed in filenames on common
+// filesystems, replaced by underscores. It returns an empty string, if nothing usable is left.
+func sanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	return strings.Trim(name, " .")
+}
+
+// uniqueName returns the name, or the name with a numeric suffix before its extension, which is not
+// among the used names yet, and marks it as used.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	ext := path.Ext(name)
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), i, ext)
+	}
+	used[unique] = true
+	return unique
+}
+
+// Aria2Options are the options of the aria2c input file output.
+type Aria2Options struct {
+	// ASCIIFilenames enables transliterating the directory and file names to ASCII, see ASCIIFilename,
+	// for filesystems that don't handle diacritics well.
+	ASCIIFilenames bool
+}
+
+// asciiTransformer removes the diacritics by decomposing the characters and dropping the combining marks.
+var asciiTransformer = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// ASCIIFilename transliterates the Czech characters in the filename to ASCII by removing their diacritics,
+// e.g. "Veřejná výzva.pdf" becomes "Verejna vyzva.pdf". Any other non-ASCII characters are replaced by "_".
+func ASCIIFilename(name string) string {
+	if ascii, _, err := transform.String(asciiTransformer, name); err == nil {
+		name = ascii
+	}
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// WriteAria2 writes the attachments of the news entries in the aria2c input file format. Each attachment
+// URL is resolved against the news entry URL and followed by the "dir" option with a directory unique to
+// the news entry and the "out" option with the sanitized attachment filename, unique within the directory.
+// The filenames of the attachments in the news entries are kept as they are.
+func (n News) WriteAria2(w io.Writer, opts Aria2Options) error {
+	usedDirs := map[string]bool{}
+	for _, newsEntry := range n {
+		if len(newsEntry.Attachments) == 0 {
+			continue
+		}
+		entryURL, err := newsEntry.URL()
+		if err != nil {
+			return err
+		}
+
+		dir := sanitizeFilename(path.Base(entryURL.Path))
+		if dir == "" {
+			dir = sanitizeFilename(newsEntry.Title)
+		}
+		if dir == "" {
+			dir = "entry"
+		}
+		if opts.ASCIIFilenames {
+			dir = ASCIIFilename(dir)
+		}
+		dir = uniqueName(dir, usedDirs)
+
+		usedNames := map[string]bool{}
+		for _, attachment := range newsEntry.Attachments {
+			attachmentURL, err := entryURL.Parse(attachment.URL)
+			if err != nil {
+				return err
+			}
+
+			name := sanitizeFilename(CleanAttachmentFilename(attachment.Filename))
+			if ext := path.Ext(attachmentURL.Path); ext != "" && !strings.EqualFold(path.Ext(name), ext) {
+				name += ext
+			}
+			if strings.TrimSuffix(name, path.Ext(name)) == "" {
+				name = sanitizeFilename(path.Base(attachmentURL.Path))
+			}
+			if name == "" {
+				name = "attachment"
+			}
+			if opts.ASCIIFilenames {
+				name = ASCIIFilename(name)
+			}
+			name = uniqueName(name, usedNames)
+
+			_, err = fmt.Fprintf(w, "%s\n  dir=%s\n  out=%s\n", attachmentURL, dir, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
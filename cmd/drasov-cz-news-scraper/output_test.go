@@ -0,0 +1,78 @@
+/*
+ * www.drasov.cz/uredni-deska news scraper
+ *
+ * Copyright (C) 2023  Tomáš Hozza
+ */
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestASCIIFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Veřejná výzva.pdf", "Verejna vyzva.pdf"},
+		{"Žádost o účast – příloha č. 1.docx", "Zadost o ucast _ priloha c. 1.docx"},
+		{"ŘÍJEN 2026.pdf", "RIJEN 2026.pdf"},
+		{"report.pdf", "report.pdf"},
+	}
+	for _, tt := range tests {
+		if got := ASCIIFilename(tt.name); got != tt.want {
+			t.Errorf("ASCIIFilename(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriteAria2ASCIIFilenames(t *testing.T) {
+	news := News{{
+		Title:       "Veřejná výzva",
+		EntryURL:    "https://www.drasov.cz/uredni-deska/verejna-vyzva",
+		Attachments: []NewsEntryAttachment{{Filename: "Veřejná výzva.pdf", URL: "/files/vyzva.pdf"}},
+	}}
+
+	var sb strings.Builder
+	if err := news.WriteAria2(&sb, Aria2Options{ASCIIFilenames: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := "https://www.drasov.cz/files/vyzva.pdf\n  dir=verejna-vyzva\n  out=Verejna vyzva.pdf\n"
+	if sb.String() != want {
+		t.Errorf("unexpected aria2 input:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
+
+func TestValidCSVDelimiter(t *testing.T) {
+	for _, r := range []rune{',', ';', '\t', '|'} {
+		if !ValidCSVDelimiter(r) {
+			t.Errorf("expected %q to be a valid delimiter", r)
+		}
+	}
+	for _, r := range []rune{0, '"', '\r', '\n', utf8.RuneError} {
+		if ValidCSVDelimiter(r) {
+			t.Errorf("expected %q to be an invalid delimiter", r)
+		}
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	news := News{{Title: "Oznámení", EntryURL: "https://www.drasov.cz/uredni-deska/1"}}
+
+	var sb strings.Builder
+	if err := news.WriteCSV(&sb, CSVOptions{Fields: []string{"title", "body"}}); err == nil {
+		t.Errorf("expected an error of WriteCSV for an unknown field")
+	}
+	if sb.Len() > 0 {
+		t.Errorf("expected no CSV output for an unknown field, got %q", sb.String())
+	}
+	if err := (TextRenderer{Fields: []string{"body"}}).Render(&sb, news); err == nil {
+		t.Errorf("expected an error of TextRenderer for an unknown field")
+	}
+	if err := news.WriteCSV(&sb, CSVOptions{Fields: []string{"title", "url"}}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * www.drasov.cz/uredni-deska news scraper
+ *
+ * Copyright (C) 2023  Tomáš Hozza
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy defines how many times and with which delays an operation is retried.
+// The delay doubles with each retry, starting at BaseDelay, up to MaxDelay.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	// Values lower than 1 mean a single attempt, i.e. no retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries. If zero, the delay is not capped.
+	MaxDelay time.Duration
+	// Jitter enables randomizing each delay between its half and its full value,
+	// so that several clients don't retry at the same time.
+	Jitter bool
+}
+
+// permanentError wraps an error, which should not be retried.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps the error returned by a RetryPolicy.Do function to stop retrying.
+// RetryPolicy.Do returns the original error.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// retryAfterError wraps an error, which should be retried only after the given delay.
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// RetryAfter wraps the error returned by a RetryPolicy.Do function to retry it after the given delay,
+// instead of the delay of the policy, e.g. as requested by the server. The delay is still capped
+// by the MaxDelay of the policy.
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return retryAfterError{err: err, delay: delay}
+}
+
+// ParseRetryAfter parses the value of the Retry-After HTTP header, which is either a number of seconds,
+// or an HTTP date, into the delay from the given time. It reports false, if the value can't be parsed.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+// Do calls fn until it succeeds, returns a Permanent error, the maximum number of attempts is reached,
+// or the context is done. It returns the last error returned by fn, or the context error.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if err := p.wait(ctx, attempt, err); err != nil {
+			return err
+		}
+	}
+}
+
+// wait waits before retrying the given failed attempt, which failed with err. It returns nil, if the operation
+// should be retried, otherwise the error to give up with, see Do.
+func (p RetryPolicy) wait(ctx context.Context, attempt int, err error) error {
+	var permanent permanentError
+	if errors.As(err, &permanent) {
+		return permanent.err
+	}
+	if attempt >= p.MaxAttempts {
+		return err
+	}
+
+	delay := p.delay(attempt)
+	var retryAfter retryAfterError
+	if errors.As(err, &retryAfter) {
+		delay = retryAfter.delay
+		// a server may ask for an absurd delay, e.g. hours
+		if p.MaxDelay > 0 && delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+	}
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// delay returns the delay after the given failed attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay == 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter && delay > 1 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+	return delay
+}
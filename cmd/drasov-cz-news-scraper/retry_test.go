@@ -0,0 +1,36 @@
+/*
+ * www.drasov.cz/uredni-deska news scraper
+ *
+ * Copyright (C) 2023  Tomáš Hozza
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyCapsRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	attempts := 0
+	start := time.Now()
+	err := policy.Do(context.Background(), func() error {
+		attempts++
+		if attempts == 1 {
+			return RetryAfter(errors.New("too many requests"), time.Hour)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got %s", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the Retry-After delay to be capped by MaxDelay, waited %s", elapsed)
+	}
+}
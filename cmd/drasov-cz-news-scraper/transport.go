@@ -0,0 +1,160 @@
+/*
+ * www.drasov.cz/uredni-deska news scraper
+ *
+ * Copyright (C) 2023  Tomáš Hozza
+ */
+
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxIdleConnsPerHost is the default number of idle connections kept for reuse per host,
+// see newTransport.
+const defaultMaxIdleConnsPerHost = 16
+
+// newTransport returns a clone of http.DefaultTransport tuned for many requests to the same host, which keeps
+// up to the given number of idle connections per host, instead of the default 2, so that the connections
+// are reused by all threads fetching the details pages. HTTP/2 is used, if the server supports it.
+func newTransport(maxIdleConnsPerHost int) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	transport.ForceAttemptHTTP2 = true
+	return transport
+}
+
+// contextTransport is a http.RoundTripper which makes all requests with the given context,
+// so that they are cancelled when the context is done.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// acceptEncoding is the value of the Accept-Encoding header sent by decompressingTransport.
+const acceptEncoding = "gzip, deflate"
+
+// decompressingTransport is a http.RoundTripper which explicitly accepts compressed responses and
+// decodes them, so that the response body is always plain. The http.Transport transparently decodes
+// only gzip and only if the request doesn't set the Accept-Encoding header itself.
+type decompressingTransport struct {
+	base http.RoundTripper
+}
+
+func (t decompressingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.ReadCloser
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		body, err = gzip.NewReader(resp.Body)
+	case "deflate":
+		body, err = newDeflateReader(resp.Body)
+	default:
+		return resp, nil
+	}
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	resp.Body = decompressedBody{ReadCloser: body, compressed: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// newDeflateReader returns a decoder of the "deflate" content encoding, which should be zlib-wrapped,
+// but some servers send raw deflate data instead, so it falls back to raw deflate, if the zlib header
+// is invalid.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	// the compression method is deflate and the header checksum is valid, see RFC 1950
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decompressedBody reads the decoded response body and closes both the decoder and the original body.
+type decompressedBody struct {
+	io.ReadCloser
+	compressed io.Closer
+}
+
+func (b decompressedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if cerr := b.compressed.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ErrBodyTooLarge is returned when a response body exceeds the configured maximum size.
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// bodyLimitTransport is a http.RoundTripper which fails reading of a response body, once it exceeds
+// the given number of bytes, instead of silently truncating it.
+type bodyLimitTransport struct {
+	base  http.RoundTripper
+	limit int64
+}
+
+func (t bodyLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.limit, limit: t.limit}
+	return resp, nil
+}
+
+// limitedBody reads at most limit bytes of the body and returns ErrBodyTooLarge, if there are more.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("%w: exceeds %d bytes", ErrBodyTooLarge, b.limit)
+	}
+	// read one byte over the limit to detect a larger body
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), fmt.Errorf("%w: exceeds %d bytes", ErrBodyTooLarge, b.limit)
+	}
+	return n, err
+}
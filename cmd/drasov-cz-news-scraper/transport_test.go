@@ -0,0 +1,139 @@
+/*
+ * www.drasov.cz/uredni-deska news scraper
+ *
+ * Copyright (C) 2023  Tomáš Hozza
+ */
+
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDecompressingTransportDeflate(t *testing.T) {
+	const page = "<html><body>Úřední deska</body></html>"
+
+	var zlibBody, rawBody bytes.Buffer
+	zw := zlib.NewWriter(&zlibBody)
+	zw.Write([]byte(page))
+	zw.Close()
+	fw, err := flate.NewWriter(&rawBody, flate.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(page))
+	fw.Close()
+
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"zlib", zlibBody.Bytes()},
+		{"raw deflate", rawBody.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", "deflate")
+				w.Write(tt.body)
+			}))
+			defer server.Close()
+
+			client := http.Client{Transport: decompressingTransport{base: http.DefaultTransport}}
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != page {
+				t.Errorf("expected the body %q, got %q", page, body)
+			}
+			if resp.Header.Get("Content-Encoding") != "" {
+				t.Errorf("expected no Content-Encoding, got %q", resp.Header.Get("Content-Encoding"))
+			}
+		})
+	}
+}
+
+// connCountingServer starts a server serving the given pages, which counts the new connections.
+func connCountingServer(tb testing.TB, pages map[string]string) (*httptest.Server, *int64) {
+	tb.Helper()
+	var conns int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	server.Start()
+	tb.Cleanup(server.Close)
+	return server, &conns
+}
+
+func TestScrapeReusesConnections(t *testing.T) {
+	const threads = 8
+	server, conns := connCountingServer(t, largeBoard(40, 0))
+
+	scrape(t, ScraperConfig{
+		Sources:       []Source{{Name: "test", URL: server.URL + "/uredni-deska"}},
+		DetailThreads: threads,
+	})
+	// about a connection for each thread, while a new connection may be dialed, before another one
+	// is released, but far less than a connection for each of the 41 requests
+	if n := atomic.LoadInt64(conns); n > 2*threads {
+		t.Errorf("expected at most %d connections, got %d", 2*threads, n)
+	}
+}
+
+func BenchmarkScrapeConnections(b *testing.B) {
+	const threads = 8
+	untuned := http.DefaultTransport.(*http.Transport).Clone()
+	benchmarks := []struct {
+		name      string
+		transport func() http.RoundTripper
+	}{
+		// keeps only 2 idle connections per host, so most threads open a new connection for each request
+		{"default", func() http.RoundTripper { return untuned }},
+		{"tuned", func() http.RoundTripper { return newTransport(defaultMaxIdleConnsPerHost) }},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			server, conns := connCountingServer(b, largeBoard(100, 0))
+			logger, _ := testLogger()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				scraper := NewScraper(ScraperConfig{
+					Sources:       []Source{{Name: "test", URL: server.URL + "/uredni-deska"}},
+					DetailThreads: threads,
+					Transport:     bm.transport(),
+					Logger:        logger,
+				})
+				if _, err := scraper.Scrape(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(conns))/float64(b.N), "conns/op")
+		})
+	}
+}